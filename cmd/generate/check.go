@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/changes"
+	"github.com/flopp/parkrun-map/internal/linkcheck"
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/report"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// checkCourses compares the data source with the course pages (route ID, start time, meeting point) and the course KML
+// files (coordinates).
+func (s *site) checkCourses(r *report.Report) {
+	const check = "course"
+	log.Printf("CHECKING COURSES")
+
+	for _, event := range s.events {
+		log.Printf("    CHECKING %s", event.Id)
+		r.Checked(check, event.Id)
+
+		// parse the course page (map, description, start time, meeting point)
+		course_url := event.CoursePageUrl()
+		course_file := s.download.Path("parkrun", event.Id, "course_page")
+		if err := utils.DownloadFileIfOlder(course_url, course_file, s.maxAge(age1w)); err != nil {
+			r.Error(check, event.Id, course_url, "downloading course page: %v", err)
+			continue
+		}
+		if err := event.LoadCoursePage(course_file); err != nil {
+			r.Error(check, event.Id, course_url, "parsing course page: %v", err)
+			continue
+		}
+		if event.Course.Description == "" {
+			r.Warning(check, event.Id, course_url, "no course description on course page")
+		}
+
+		// compare the course page with the data source; a different route makes the remaining checks pointless
+		routeMismatch := false
+		for _, m := range event.CourseMismatches() {
+			evidence := fmt.Sprintf("course page: %s, data source: %s", m.CoursePage, m.DataSource)
+			if m.Field == "route ID" {
+				routeMismatch = true
+				r.Error(check, event.Id, evidence, "route ID from course page does not match route ID from data source")
+			} else {
+				r.Warning(check, event.Id, evidence, "%s from course page does not match data source", m.Field)
+			}
+		}
+		if routeMismatch {
+			continue
+		}
+
+		// check distance between coordinates from Google Sheets and coordinates from the course
+		kml_url := event.GoogleMapsCourseKmlUrl()
+		kml_file := s.download.Path("parkrun", event.Id, event.GoogleMapsCourseId())
+		if err := utils.DownloadFileIfOlder(kml_url, kml_file, s.now.Add(randomDuration(-24*200*time.Hour, -24*100*time.Hour))); err != nil {
+			r.Error(check, event.Id, kml_url, "downloading course KML: %v", err)
+			continue
+		}
+		if err := event.LoadKML(kml_file); err != nil {
+			r.Error(check, event.Id, kml_file, "parsing course KML: %v", err)
+			continue
+		}
+
+		if !event.CoordsFromKml.IsValid() {
+			r.Error(check, event.Id, kml_url, "coordinates from KML are not valid, cannot check distance to data source coordinates")
+		} else if !event.Coords.IsValid() {
+			r.Error(check, event.Id, "", "coordinates from data source are not valid, cannot check distance to KML coordinates")
+		} else if distance := utils.DistanceMeters(event.Coords, event.CoordsFromKml); distance > 10 {
+			r.Error(check, event.Id, fmt.Sprintf("data source: %f,%f, KML: %f,%f", event.Coords.Lat, event.Coords.Lon, event.CoordsFromKml.Lat, event.CoordsFromKml.Lon), "distance between data source coordinates and KML coordinates is %.0fm (more than 10m)", distance)
+		}
+	}
+}
+
+// checkEventsJson reconciles parkrun's events.json with the data source and with the events.json of the last
+// deployment: events missing on either side, new and removed events, renamed events and moved events. The snapshot of
+// the last build can't be used, as "build" (running before "check") has already replaced it with the current state.
+func (s *site) checkEventsJson(r *report.Report) {
+	const check = "events-json"
+	log.Printf("CHECKING EVENTS.JSON")
+
+	var prev []parkrun.EventsJsonEntry
+	snapshotFile := deployedSnapshotFile(s.download)
+	if snapshot, err := changes.LoadSnapshot(snapshotFile); err != nil {
+		r.Warning(check, "", snapshotFile, "loading snapshot of the last deployment: %v", err)
+	} else if snapshot != nil && len(snapshot.EventsJson) > 0 {
+		prev = snapshot.EventsJson
+	}
+
+	r.Checked(check, "")
+	for _, e := range s.eventsJson {
+		r.Checked(check, e.Id)
+	}
+	reconciliation := parkrun.Reconcile(prev, s.eventsJson, s.infos, parkrun.DefaultMoveThreshold)
+	for _, info := range reconciliation.MissingInEventsJson {
+		if info.Status == parkrun.StatusPlanned {
+			r.Info(check, info.Id, info.Name, "planned event is not in events.json yet")
+		} else {
+			r.Warning(check, info.Id, fmt.Sprintf("%s (%s)", info.Name, info.Status), "event of the data source is missing in events.json")
+		}
+	}
+	for _, e := range reconciliation.MissingInSource {
+		r.Error(check, e.Id, e.LongName, "event of events.json has no row in the data source (no state, city or route)")
+	}
+	for _, e := range reconciliation.New {
+		r.Info(check, e.Id, e.LongName, "new event in events.json")
+	}
+	for _, e := range reconciliation.Removed {
+		r.Warning(check, e.Id, e.LongName, "event has been removed from events.json")
+	}
+	for _, rename := range reconciliation.Renamed {
+		r.Warning(check, rename.NewId, fmt.Sprintf("%s (%s) -> %s (%s)", rename.OldId, rename.OldLongName, rename.NewId, rename.NewLongName), "event has been renamed in events.json")
+	}
+	for _, move := range reconciliation.Moved {
+		r.Warning(check, move.Id, fmt.Sprintf("%f,%f -> %f,%f", move.From.Lat, move.From.Lon, move.To.Lat, move.To.Lon), "coordinates in events.json moved by %.0fm", move.DistanceMeters)
+	}
+}
+
+// checkSpecialDays verifies that the special days calendar only lists known events.
+func (s *site) checkSpecialDays(r *report.Report) {
+	const check = "special-days"
+	log.Printf("CHECKING SPECIAL DAYS")
+
+	r.Checked(check, "")
+	for _, id := range s.specialDays.UnknownEvents(s.events) {
+		r.Error(check, id, "specialdays.json", "unknown event in special days calendar")
+	}
+}
+
+// checkCancellations verifies that every cancellation from the wiki belongs to a known event (otherwise the event name probably changed).
+func (s *site) checkCancellations(r *report.Report) {
+	const check = "cancellations"
+	log.Printf("CHECKING CANCELLATIONS")
+
+	r.Checked(check, "")
+	unmatchedCancellations, err := loadCancellations(s.download, s.events, s.now, s.maxAge(age1d))
+	if err != nil {
+		r.Error(check, "", "", "loading cancellations: %v", err)
+		return
+	}
+	for _, c := range unmatchedCancellations {
+		r.Error(check, "", c.EventName, "cancellation on %s for unknown event '%s'", c.DateF(), c.EventName)
+	}
+}
+
+// checkLinks checks all links of the events concurrently: missing pages are errors, other failures (server and network
+// errors, "not found" pages with status 200) are warnings; redirects (moved URLs) and sites blocking bots are reported as infos.
+func (s *site) checkLinks(r *report.Report, checker *linkcheck.Checker) {
+	const check = "links"
+	log.Printf("CHECKING LINKS")
+
+	urls := make([]string, 0)
+	for _, event := range s.events {
+		for _, link := range event.Links() {
+			urls = append(urls, link.Url)
+		}
+	}
+	results, err := checker.Check(urls)
+	if err != nil {
+		r.Warning(check, "", checker.CacheFile, "writing link cache: %v", err)
+	}
+
+	for _, event := range s.events {
+		r.Checked(check, event.Id)
+		for _, link := range event.Links() {
+			result := results[link.Url]
+			switch result.Status {
+			case linkcheck.StatusBroken:
+				if result.Code == http.StatusNotFound || result.Code == http.StatusGone {
+					r.Error(check, event.Id, link.Url, "%s link is broken: %s", link.Name, result.Message)
+				} else {
+					r.Warning(check, event.Id, link.Url, "%s link is broken: %s", link.Name, result.Message)
+				}
+			case linkcheck.StatusError:
+				r.Warning(check, event.Id, link.Url, "%s link is unreachable: %s", link.Name, result.Message)
+			case linkcheck.StatusSoft404:
+				r.Warning(check, event.Id, link.Url, "%s link probably doesn't exist anymore: %s", link.Name, result.Message)
+			case linkcheck.StatusRedirect:
+				r.Info(check, event.Id, fmt.Sprintf("%s -> %s", link.Url, result.FinalUrl), "%s link has moved", link.Name)
+			case linkcheck.StatusBlocked:
+				r.Info(check, event.Id, link.Url, "%s link could not be checked, the site blocks bots: %s", link.Name, result.Message)
+			}
+		}
+	}
+}
+
+// check runs all checks of the loaded site and collects their findings.
+func (s *site) check(links *linkcheck.Checker) *report.Report {
+	r := report.New()
+	s.checkCourses(r)
+	s.checkEventsJson(r)
+	s.checkSpecialDays(r)
+	s.checkCancellations(r)
+	s.checkLinks(r, links)
+	return r
+}
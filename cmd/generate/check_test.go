@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/report"
+)
+
+func TestCheckEventsJsonAfterBuild(t *testing.T) {
+	dietenbach := &parkrun.Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Dietenbacher Park", CountryUrl: "www.parkrun.com.de"}
+	dietenbach.LatestRun = &parkrun.Run{Event: dietenbach, Index: 100, Date: time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), RunnerCount: 50}
+	output := t.TempDir()
+	s := &site{
+		opts:     options{dataDir: "../../data", outputDir: output},
+		config:   Config{Domain: "example.com"},
+		now:      time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		data:     PathBuilder("../../data"),
+		download: PathBuilder(t.TempDir()),
+		output:   PathBuilder(output),
+		infos:    map[string]*parkrun.ParkrunInfo{"dietenbach": {Id: "dietenbach"}},
+		events:   []*parkrun.Event{dietenbach},
+	}
+	build := func(longName string) {
+		t.Helper()
+		s.eventsJson = []parkrun.EventsJsonEntry{{Id: "dietenbach", LongName: longName, Lat: 47.99, Lon: 7.8}}
+		if err := s.loadManifest(); err != nil {
+			t.Fatalf("loadManifest() error = %v", err)
+		}
+		renderData := s.newRenderData()
+		if err := s.renderPages(renderData); err != nil {
+			t.Fatalf("renderPages() error = %v", err)
+		}
+		if err := s.write(renderData); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	renamed := func() bool {
+		r := report.New()
+		s.checkEventsJson(r)
+		for _, f := range r.Findings {
+			if f.Message == "event has been renamed in events.json" {
+				return true
+			}
+		}
+		return false
+	}
+
+	build("Dietenbach parkrun")
+	if err := saveDeployedSnapshot(s.download); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next build replaces the build snapshot before the check runs; the rename is still reported
+	build("Dietenbach parkrun, Freiburg")
+	if !renamed() {
+		t.Fatalf("rename not reported after build")
+	}
+
+	// once deployed, the rename is no longer new
+	if err := saveDeployedSnapshot(s.download); err != nil {
+		t.Fatal(err)
+	}
+	if renamed() {
+		t.Fatalf("rename reported after deployment")
+	}
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/changes"
+	"github.com/flopp/parkrun-map/internal/datasource"
+	"github.com/flopp/parkrun-map/internal/linkcheck"
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/report"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// command is a subcommand of the generator with its own flags.
+type command struct {
+	name        string
+	args        string // positional arguments, shown in the usage line
+	description string
+	// setup registers the command's flags and returns the function running the command with the remaining arguments
+	setup func(flags *flag.FlagSet) func(args []string) error
+}
+
+// errFailed signals a command that ran, but failed (e.g. lint errors); the details have already been printed.
+var errFailed = errors.New("failed")
+
+var commands = []command{
+	{"build", "", "download the data and generate the site", buildCommand},
+	{"check", "", "check courses, special days, cancellations and links of all events", checkCommand},
+	{"export", "", "export all events as CSV and GeoJSON", exportCommand},
+	{"serve", "", "serve the generated site", serveCommand},
+	{"lint", "", "validate all rows of the data source and report all problems at once", lintCommand},
+	{"cache", "[list|clean]", "list or clean the download cache", cacheCommand},
+	{"diff", "OLD_SNAPSHOT NEW_SNAPSHOT", "show the changes between two build snapshots", diffCommand},
+	{"deploy", "[TARGET]", "upload the changed files of the last build to a deploy target of the config file", deployCommand},
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: generate COMMAND [flags] [args]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(w, "\nrun 'generate help COMMAND' for the flags of a command\n")
+}
+
+// newFlagSet creates the flag set of the command and its run function.
+func (cmd command) newFlagSet(output io.Writer) (*flag.FlagSet, func(args []string) error) {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	run := cmd.setup(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(output, "usage: generate %s [flags] %s\n\n%s\n\nflags:\n", cmd.name, cmd.args, cmd.description)
+		flags.PrintDefaults()
+	}
+	return flags, run
+}
+
+// runCommand parses the command line (without the program name) and runs the selected command.
+func runCommand(args []string, stderr io.Writer) error {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return errFailed
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		if len(args) > 1 {
+			cmd := findCommand(args[1])
+			if cmd == nil {
+				return fmt.Errorf("unknown command '%s'", args[1])
+			}
+			flags, _ := cmd.newFlagSet(stderr)
+			flags.Usage()
+			return nil
+		}
+		printUsage(stderr)
+		return nil
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		printUsage(stderr)
+		return fmt.Errorf("unknown command '%s'", args[0])
+	}
+	flags, run := cmd.newFlagSet(stderr)
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errFailed
+	}
+	return run(flags.Args())
+}
+
+func buildCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	opts.registerOutput(flags)
+	clean := flags.Bool("clean", false, "remove the output directory before building")
+	noValidate := flags.Bool("no-validate", false, "don't validate the output directory after building")
+	return func(args []string) error {
+		opts.setupLogging()
+		if *clean {
+			if err := os.RemoveAll(opts.outputDir); err != nil {
+				return fmt.Errorf("while removing %s: %w", opts.outputDir, err)
+			}
+		}
+		utils.SetDownloadDelay(2 * time.Second)
+
+		s, err := loadSite(opts, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := s.enrich(); err != nil {
+			return err
+		}
+		renderData, err := s.render()
+		if err != nil {
+			return err
+		}
+		if err := s.write(renderData); err != nil {
+			return err
+		}
+		if *noValidate {
+			return nil
+		}
+		r, err := s.validate(renderData)
+		if err != nil {
+			return recordStage(s.download, "validate", err)
+		}
+		return recordStage(s.download, "validate", writeReport(r, os.Stdout, "", ""))
+	}
+}
+
+// checkCommand runs all checks and reports all findings; it fails only if there are error-level findings.
+func checkCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	jsonFile := flags.String("json", "", "write the report as JSON to this file")
+	junitFile := flags.String("junit", "", "write the report as JUnit XML to this file (for CI)")
+	linkMaxAge := flags.Duration("link-max-age", 24*time.Hour, "reuse link check results that are younger than this")
+	linkConcurrency := flags.Int("link-concurrency", 8, "max. number of concurrent link checks")
+	linkPerHost := flags.Int("link-per-host", 2, "max. number of concurrent link checks per host")
+	return func(args []string) error {
+		opts.setupLogging()
+		utils.SetDownloadDelay(2 * time.Second)
+
+		s, err := loadSite(opts, time.Now())
+		if err != nil {
+			return recordStage(PathBuilder(opts.downloadDir), "check", err)
+		}
+		links := &linkcheck.Checker{
+			CacheFile:   s.download.Path("links", "cache.json"),
+			MaxAge:      *linkMaxAge,
+			Concurrency: *linkConcurrency,
+			PerHost:     *linkPerHost,
+		}
+		r := s.check(links)
+		return recordStage(s.download, "check", writeReport(r, os.Stdout, *jsonFile, *junitFile))
+	}
+}
+
+// writeReport prints the report as text and writes the optional JSON and JUnit files; it returns errFailed if the
+// report has errors.
+func writeReport(r *report.Report, w io.Writer, jsonFile string, junitFile string) error {
+	if err := r.WriteText(w); err != nil {
+		return err
+	}
+	for _, output := range []struct {
+		file  string
+		write func(io.Writer) error
+	}{{jsonFile, r.WriteJSON}, {junitFile, r.WriteJUnit}} {
+		if output.file == "" {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := output.write(&buf); err != nil {
+			return err
+		}
+		if err := utils.WriteFile(output.file, buf.Bytes()); err != nil {
+			return fmt.Errorf("while writing %s: %w", output.file, err)
+		}
+	}
+	if r.HasErrors() {
+		return errFailed
+	}
+	return nil
+}
+
+func exportCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	csvFile := flags.String("csv", "parkrun-events.csv", "export CSV file with all parkrun events (empty: no export)")
+	geoJsonFile := flags.String("geojson", "parkrun-events.geojson", "export GeoJSON file with all parkrun events (empty: no export)")
+	return func(args []string) error {
+		opts.setupLogging()
+		utils.SetDownloadDelay(2 * time.Second)
+
+		s, err := loadSite(opts, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := s.enrich(); err != nil {
+			return err
+		}
+		if *csvFile != "" {
+			if err := parkrun.ExportCsv(s.events, *csvFile); err != nil {
+				return fmt.Errorf("while exporting CSV: %w", err)
+			}
+		}
+		if *geoJsonFile != "" {
+			if err := parkrun.ExportGeoJson(s.events, *geoJsonFile); err != nil {
+				return fmt.Errorf("while exporting GeoJSON: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// serveCommand builds the site once and serves it like the production web server; changes of the templates,
+// static files and articles trigger a re-render and a reload of the open pages. The dev server shares the downloads of
+// the build, but uses its own output directory and build state, and doesn't update the changes feed.
+func serveCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	opts.registerOutput(flags)
+	// keep the production build (output, build manifest, changes snapshot and feed) untouched; the downloads are shared
+	setFlagDefault(flags, "output", ".output-dev")
+	flags.StringVar(&opts.stateDir, "state", ".download-dev", "the directory of the build manifest, changes snapshot and feed and planned history of the dev server")
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	noBuild := flags.Bool("no-build", false, "serve the existing output directory without building and watching")
+	interval := flags.Duration("interval", 500*time.Millisecond, "the polling interval for watching the data directory")
+	return func(args []string) error {
+		opts.setupLogging()
+		server := newDevServer(opts.outputDir)
+
+		if !*noBuild {
+			utils.SetDownloadDelay(2 * time.Second)
+			s, err := loadSite(opts, time.Now())
+			if err != nil {
+				return err
+			}
+			s.preview = true
+			if err := s.enrich(); err != nil {
+				return err
+			}
+			if err := s.rerender(); err != nil {
+				return err
+			}
+			server.reloadHtaccess()
+
+			dirs := []string{s.data.Path("templates"), s.data.Path("static"), s.data.Path("articles")}
+			go func() {
+				err := watchDirs(dirs, *interval, func(changed []string) {
+					log.Printf("CHANGED: %s", strings.Join(changed, ", "))
+					if err := s.rerender(); err != nil {
+						log.Printf("while re-rendering: %v", err)
+						return
+					}
+					server.reloadHtaccess()
+					server.notify()
+				})
+				if err != nil {
+					log.Printf("while watching %v: %v", dirs, err)
+				}
+			}()
+		}
+
+		fmt.Printf("SERVING %s TO http://%s/\n", opts.outputDir, *addr)
+		return http.ListenAndServe(*addr, server)
+	}
+}
+
+// lintCommand validates all rows of the data source and prints all problems with their row numbers.
+// It fails if there are errors (warnings are printed, but don't fail).
+func lintCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	offline := flags.Bool("offline", false, "don't download events.json, skip the events.json checks if there's no cached copy")
+	run := func(args []string) error {
+		opts.setupLogging()
+		config, err := loadConfig(opts.configFile)
+		if err != nil {
+			return err
+		}
+		source, err := newDataSource(config)
+		if err != nil {
+			return fmt.Errorf("while creating data source: %w", err)
+		}
+		data, planned, err := source.LoadRows()
+		if err != nil {
+			return fmt.Errorf("while loading data: %w", err)
+		}
+
+		download := PathBuilder(opts.downloadDir)
+		events_json_url := "https://images.parkrun.com/events.json"
+		events_json_file := download.Path("parkrun", "events.json.gz")
+		if !*offline {
+			if err := utils.DownloadFileIfOlder(events_json_url, events_json_file, time.Now().Add(-age1d)); err != nil {
+				return fmt.Errorf("while downloading %s to %s: %w", events_json_url, events_json_file, err)
+			}
+		}
+		var eventIds map[string]struct{}
+		if _, err := os.Stat(events_json_file); err == nil {
+			eventIds, err = parkrun.LoadEventIds(events_json_file, true /* germanyOnly */)
+			if err != nil {
+				return fmt.Errorf("while loading events: %w", err)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "%s not available, skipping events.json checks\n", events_json_file)
+		}
+
+		problems := datasource.Lint(data, planned, eventIds)
+		datasource.SortProblems(problems)
+		errorCount := 0
+		for _, problem := range problems {
+			fmt.Println(problem)
+			if problem.Severity == datasource.SeverityError {
+				errorCount += 1
+			}
+		}
+		fmt.Printf("%d rows checked, %d errors, %d warnings\n", len(data.Rows)+len(planned.Rows), errorCount, len(problems)-errorCount)
+
+		if datasource.HasErrors(problems) {
+			return errFailed
+		}
+		return nil
+	}
+	// the result is recorded for the deploy command
+	return func(args []string) error {
+		return recordStage(PathBuilder(opts.downloadDir), "lint", run(args))
+	}
+}
+
+// volatileCacheFiles are the downloads that change frequently; "cache clean" removes them to force a refresh.
+var volatileCacheFiles = [][]string{
+	{"parkrun", "events.json.gz"},
+	{"parkrun", "summary_wiki"},
+	{"parkrun", "cancellations_wiki"},
+}
+
+// cacheCommand lists the files of the download directory or removes the frequently changing ones.
+func cacheCommand(flags *flag.FlagSet) func(args []string) error {
+	downloadDir := flags.String("download", ".download", "the download directory")
+	all := flags.Bool("all", false, "clean: remove the whole download directory (including the build snapshot and histories)")
+	return func(args []string) error {
+		action := "list"
+		if len(args) > 0 {
+			action = args[0]
+		}
+		switch action {
+		case "list":
+			return listCache(os.Stdout, *downloadDir, time.Now())
+		case "clean":
+			if *all {
+				return os.RemoveAll(*downloadDir)
+			}
+			download := PathBuilder(*downloadDir)
+			for _, items := range volatileCacheFiles {
+				if err := os.Remove(download.Path(items...)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown cache action '%s' (expected 'list' or 'clean')", action)
+	}
+}
+
+// listCache prints the files of the download directory with their size and age.
+func listCache(w io.Writer, downloadDir string, now time.Time) error {
+	type entry struct {
+		path string
+		size int64
+		age  time.Duration
+	}
+	entries := make([]entry, 0)
+	err := filepath.WalkDir(downloadDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(downloadDir, path)
+		entries = append(entries, entry{rel, info.Size(), now.Sub(info.ModTime())})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	var total int64
+	for _, e := range entries {
+		fmt.Fprintf(w, "%10d  %-12s  %s\n", e.size, e.age.Truncate(time.Minute), e.path)
+		total += e.size
+	}
+	fmt.Fprintf(w, "%d files, %d bytes\n", len(entries), total)
+	return nil
+}
+
+// diffCommand prints a human-readable report of the changes between two build snapshots.
+func diffCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 2 {
+			flags.Usage()
+			return errFailed
+		}
+
+		snapshots := make([]*changes.Snapshot, 0, 2)
+		for _, filePath := range args {
+			snapshot, err := changes.LoadSnapshot(filePath)
+			if err != nil {
+				return err
+			}
+			if snapshot == nil {
+				return fmt.Errorf("snapshot file %s does not exist", filePath)
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+
+		return changes.WriteReport(os.Stdout, *snapshots[0], *snapshots[1])
+	}
+}
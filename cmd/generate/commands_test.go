@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/report"
+)
+
+func TestRunCommandUsage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		wantErr  bool
+		contains string
+	}{
+		{name: "no command", args: nil, wantErr: true, contains: "usage: generate COMMAND"},
+		{name: "help", args: []string{"help"}, contains: "  serve    serve the generated site"},
+		{name: "command help", args: []string{"help", "build"}, contains: "-no-rewrite"},
+		{name: "command -h", args: []string{"lint", "-h"}, contains: "-offline"},
+		{name: "unknown command", args: []string{"publish"}, wantErr: true, contains: "commands:"},
+		{name: "unknown flag", args: []string{"diff", "-foo"}, wantErr: true, contains: "flag provided but not defined"},
+		{name: "missing arguments", args: []string{"diff", "a.json"}, wantErr: true, contains: "usage: generate diff [flags] OLD_SNAPSHOT NEW_SNAPSHOT"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			err := runCommand(tc.args, &stderr)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("runCommand(%v) error = %v, wantErr %v", tc.args, err, tc.wantErr)
+			}
+			if !strings.Contains(stderr.String(), tc.contains) {
+				t.Fatalf("runCommand(%v) output doesn't contain %q:\n%s", tc.args, tc.contains, stderr.String())
+			}
+		})
+	}
+}
+
+func TestCacheCommand(t *testing.T) {
+	download := t.TempDir()
+	for _, file := range []string{"parkrun/events.json.gz", "parkrun/summary_wiki", "parkrun/dietenbach/wiki", "changes/snapshot.json"} {
+		path := filepath.Join(download, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var list bytes.Buffer
+	if err := listCache(&list, download, time.Now()); err != nil {
+		t.Fatalf("listCache() error = %v", err)
+	}
+	if !strings.Contains(list.String(), "parkrun/dietenbach/wiki") || !strings.Contains(list.String(), "4 files, 4 bytes") {
+		t.Fatalf("unexpected cache list:\n%s", list.String())
+	}
+
+	var stderr bytes.Buffer
+	if err := runCommand([]string{"cache", "-download", download, "clean"}, &stderr); err != nil {
+		t.Fatalf("cache clean error = %v", err)
+	}
+	for file, wantExists := range map[string]bool{"parkrun/events.json.gz": false, "parkrun/summary_wiki": false, "parkrun/dietenbach/wiki": true, "changes/snapshot.json": true} {
+		_, err := os.Stat(filepath.Join(download, file))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s: exists = %v, want %v", file, exists, wantExists)
+		}
+	}
+
+	if err := runCommand([]string{"cache", "-download", download, "purge"}, &stderr); err == nil || errors.Is(err, errFailed) {
+		t.Fatalf("cache purge: expected error, got %v", err)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir := t.TempDir()
+	r := report.New()
+	r.Warning("links", "dietenbach", "https://example.com", "unreachable")
+
+	var out bytes.Buffer
+	jsonFile := filepath.Join(dir, "report.json")
+	junitFile := filepath.Join(dir, "junit.xml")
+	if err := writeReport(r, &out, jsonFile, junitFile); err != nil {
+		t.Fatalf("writeReport() with warnings error = %v", err)
+	}
+	for _, file := range []string{jsonFile, junitFile} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("missing report file: %v", err)
+		}
+	}
+
+	r.Error("course", "dietenbach", "", "route ID mismatch")
+	if err := writeReport(r, &out, "", ""); !errors.Is(err, errFailed) {
+		t.Fatalf("writeReport() with errors = %v, want errFailed", err)
+	}
+	if !strings.Contains(out.String(), "1 errors, 1 warnings") {
+		t.Fatalf("unexpected text report:\n%s", out.String())
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/flopp/parkrun-map/internal/datasource"
+	"github.com/flopp/parkrun-map/internal/deploy"
+)
+
+type GoogleConfig struct {
+	ApiKey   string `json:"ApiKey"`
+	SheetsId string `json:"SheetsId"`
+}
+
+// SourceConfig selects the data source: "google" (default, uses GoogleConfig), "json" or "csv" (local files).
+type SourceConfig struct {
+	Type        string `json:"type"`
+	Path        string `json:"path"`
+	PlannedPath string `json:"planned"`
+}
+
+// DeployConfig is a deploy target: "rsync" (Host, Dir, optional Chmod), "local" (Dir) or "s3" (Endpoint, Region, Bucket,
+// Prefix, AccessKey, SecretKey; the keys default to $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY).
+type DeployConfig struct {
+	Type      string `json:"type"`
+	Host      string `json:"host"`
+	Dir       string `json:"dir"`
+	Chmod     string `json:"chmod"`
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+type Config struct {
+	Domain         string                  `json:"domain"`
+	IndexNow       string                  `json:"indexnow"`
+	Google         GoogleConfig            `json:"google"`
+	Source         SourceConfig            `json:"source"`
+	UmamiWebsiteID string                  `json:"UmamiWebsiteID"`
+	Deploy         map[string]DeployConfig `json:"deploy"`
+}
+
+func unmarshalConfig(content []byte, config *Config) error {
+	if err := json.Unmarshal(content, config); err != nil {
+		return fmt.Errorf("while unmarshalling config: %w", err)
+	}
+	return nil
+}
+
+// loadConfig reads the config file shared by all commands.
+func loadConfig(configFile string) (Config, error) {
+	var config Config
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return config, fmt.Errorf("while reading config file %s: %w", configFile, err)
+	}
+	if err := unmarshalConfig(content, &config); err != nil {
+		return config, fmt.Errorf("while parsing config file %s: %w", configFile, err)
+	}
+	return config, nil
+}
+
+// newDataSource creates the data source for ParkrunInfo and PlannedData selected in the config.
+func newDataSource(config Config) (datasource.DataSource, error) {
+	switch config.Source.Type {
+	case "", "google":
+		return datasource.GoogleSheets{ApiKey: config.Google.ApiKey, SheetsId: config.Google.SheetsId}, nil
+	case "json":
+		return datasource.JsonFile{Path: config.Source.Path}, nil
+	case "csv":
+		return datasource.CsvFile{Path: config.Source.Path, PlannedPath: config.Source.PlannedPath}, nil
+	}
+	return nil, fmt.Errorf("unknown data source type: %s", config.Source.Type)
+}
+
+func newDeployTarget(config DeployConfig) (deploy.Target, error) {
+	switch config.Type {
+	case "rsync":
+		return deploy.Rsync{Host: config.Host, Dir: config.Dir, Chmod: config.Chmod}, nil
+	case "local":
+		return deploy.LocalDir{Dir: config.Dir}, nil
+	case "s3":
+		accessKey := config.AccessKey
+		if accessKey == "" {
+			accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		secretKey := config.SecretKey
+		if secretKey == "" {
+			secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+		return deploy.S3{Endpoint: config.Endpoint, Region: config.Region, Bucket: config.Bucket, Prefix: config.Prefix, AccessKey: accessKey, SecretKey: secretKey}, nil
+	}
+	return nil, fmt.Errorf("unknown deploy target type: %s", config.Type)
+}
+
+// options are the directories and settings shared by the commands.
+type options struct {
+	dataDir      string
+	downloadDir  string
+	outputDir    string
+	stateDir     string // per-build state of the dev server (default: the download directory)
+	configFile   string
+	verbose      bool
+	disableUmami bool
+	noRewrite    bool
+	noMinify     bool
+}
+
+// registerCommon registers the flags of the data, download and config locations and the verbosity.
+func (opts *options) registerCommon(flags *flag.FlagSet) {
+	flags.StringVar(&opts.dataDir, "data", "data", "the data directory")
+	flags.StringVar(&opts.downloadDir, "download", ".download", "the download directory")
+	flags.StringVar(&opts.configFile, "config", "config.json", "the config file with the data source (Google API key and Sheets ID or local files)")
+	flags.BoolVar(&opts.verbose, "verbose", false, "verbose logging")
+}
+
+// registerOutput registers the flags that affect the generated output.
+func (opts *options) registerOutput(flags *flag.FlagSet) {
+	flags.StringVar(&opts.outputDir, "output", ".output", "the output directory")
+	flags.BoolVar(&opts.disableUmami, "disable-umami", false, "disable Umami analytics in generated output")
+	flags.BoolVar(&opts.noRewrite, "no-rewrite", false, "disable URL rewrite rules in generated output")
+	flags.BoolVar(&opts.noMinify, "no-minify", false, "don't minify the generated HTML and our own JS and CSS files")
+}
+
+// setFlagDefault changes the default value of a registered flag (e.g. to use other directories than the build).
+func setFlagDefault(flags *flag.FlagSet, name string, value string) {
+	f := flags.Lookup(name)
+	f.DefValue = value
+	_ = f.Value.Set(value)
+}
+
+func (opts options) setupLogging() {
+	if opts.verbose {
+		log.SetOutput(os.Stderr)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/deploy"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// stageStatus is the result of the last run of a stage that guards deployments (check, lint, validate).
+type stageStatus struct {
+	Ok    bool      `json:"ok"`
+	Time  time.Time `json:"time"`
+	Build string    `json:"build,omitempty"` // the build that was current when the stage ran
+}
+
+func stageStatusFile(download PathBuilder) string {
+	return download.Path("build", "status.json")
+}
+
+func loadStageStatus(download PathBuilder) (map[string]stageStatus, error) {
+	status := make(map[string]stageStatus)
+	buf, err := os.ReadFile(stageStatusFile(download))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return status, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("while parsing %s: %w", stageStatusFile(download), err)
+	}
+	return status, nil
+}
+
+// currentBuild returns the ID of the last build ("" if there is none).
+func currentBuild(download PathBuilder) string {
+	manifest, err := loadManifest(download.Path("build", "manifest.json"), "", time.Time{})
+	if err != nil {
+		return ""
+	}
+	return manifest.Build
+}
+
+// recordStage stores the result of a stage for the deploy command; it returns the stage's error.
+func recordStage(download PathBuilder, stage string, stageErr error) error {
+	status, err := loadStageStatus(download)
+	if err == nil {
+		status[stage] = stageStatus{Ok: stageErr == nil, Time: time.Now(), Build: currentBuild(download)}
+		var buf []byte
+		if buf, err = json.MarshalIndent(status, "", "  "); err == nil {
+			err = utils.WriteFile(stageStatusFile(download), buf)
+		}
+	}
+	if err != nil {
+		log.Printf("while recording %s status: %v", stage, err)
+	}
+	return stageErr
+}
+
+// deployStages are the stages that must have passed for the build to be deployed.
+var deployStages = []string{"check", "lint", "validate"}
+
+// checkStages returns an error if one of the stages failed or hasn't been run for the build.
+func checkStages(download PathBuilder, build string) error {
+	status, err := loadStageStatus(download)
+	if err != nil {
+		return err
+	}
+	problems := make([]string, 0)
+	for _, stage := range deployStages {
+		s, found := status[stage]
+		if !found || s.Build != build {
+			problems = append(problems, fmt.Sprintf("%s (not run for this build)", stage))
+		} else if !s.Ok {
+			problems = append(problems, fmt.Sprintf("%s (failed at %s)", stage, s.Time.Format("2006-01-02 15:04:05")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("refusing to deploy build '%s': %s (run them after 'generate build' or use -force)", build, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// deployedStateFile is the manifest of the last deployment to the target.
+func deployedStateFile(download PathBuilder, target string) string {
+	return download.Path("deploy", target+".json")
+}
+
+// deployCommand uploads the changed files of the last build to a target of the config file.
+func deployCommand(flags *flag.FlagSet) func(args []string) error {
+	var opts options
+	opts.registerCommon(flags)
+	flags.StringVar(&opts.outputDir, "output", ".output", "the output directory")
+	dryRun := flags.Bool("dry-run", false, "only print the files that would be uploaded and deleted")
+	force := flags.Bool("force", false, "deploy even if check, lint or validate failed or haven't been run for the current build")
+	return func(args []string) error {
+		opts.setupLogging()
+		config, err := loadConfig(opts.configFile)
+		if err != nil {
+			return err
+		}
+
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		} else if len(config.Deploy) == 1 {
+			for n := range config.Deploy {
+				name = n
+			}
+		}
+		targetConfig, found := config.Deploy[name]
+		if !found {
+			names := make([]string, 0, len(config.Deploy))
+			for n := range config.Deploy {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown deploy target '%s' (configured targets: %s)", name, strings.Join(names, ", "))
+		}
+		target, err := newDeployTarget(targetConfig)
+		if err != nil {
+			return err
+		}
+
+		download := PathBuilder(opts.downloadDir)
+		now := time.Now()
+		manifestFile := download.Path("build", "manifest.json")
+		if !utils.FileExists(manifestFile) {
+			return fmt.Errorf("no build manifest %s, run 'generate build' first", manifestFile)
+		}
+		current, err := loadManifest(manifestFile, opts.outputDir, now)
+		if err != nil {
+			return err
+		}
+		if *force {
+			log.Printf("WARNING: deploying build '%s' without checking check, lint and validate", current.Build)
+		} else if err := checkStages(download, current.Build); err != nil {
+			if !*dryRun {
+				return err
+			}
+			log.Printf("WARNING: %v", err)
+		}
+		stateFile := deployedStateFile(download, name)
+		deployed, err := loadManifest(stateFile, opts.outputDir, now)
+		if err != nil {
+			return err
+		}
+
+		plan := deploy.NewPlan(deployed.hashes(), current.hashes())
+		plan.Write(os.Stdout, target)
+		if *dryRun || plan.Empty() {
+			return nil
+		}
+		if err := target.Deploy(opts.outputDir, plan); err != nil {
+			return err
+		}
+
+		// remember the deployed state (for the next deployment and for "make diff")
+		buf, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFile(stateFile, buf); err != nil {
+			return err
+		}
+		return saveDeployedSnapshot(download)
+	}
+}
+
+// deployedSnapshotFile is the changes snapshot of the last deployed build.
+func deployedSnapshotFile(download PathBuilder) string {
+	return download.Path("changes", "deployed-snapshot.json")
+}
+
+// saveDeployedSnapshot keeps the changes snapshot of the deployed build (for "make diff" and the events.json check).
+func saveDeployedSnapshot(download PathBuilder) error {
+	snapshot, err := os.ReadFile(download.Path("changes", "snapshot.json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return utils.WriteFile(deployedSnapshotFile(download), snapshot)
+}
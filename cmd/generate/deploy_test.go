@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeployCommand(t *testing.T) {
+	download := t.TempDir()
+	output := t.TempDir()
+	target := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	config := `{"domain": "example.com", "deploy": {"web": {"type": "local", "dir": "` + filepath.ToSlash(target) + `"}}}`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// build: two files, recorded in the build manifest
+	now := time.Now()
+	manifest, err := loadManifest(PathBuilder(download).Path("build", "manifest.json"), output, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Build = "build-1"
+	for file, content := range map[string]string{"index.html": "index", "old.html": "old"} {
+		if err := manifest.write(filepath.Join(output, file), []byte(content), time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := manifest.save(PathBuilder(download).Path("build", "manifest.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := func(args ...string) error {
+		var stderr bytes.Buffer
+		return runCommand(append([]string{"deploy", "-download", download, "-output", output, "-config", configFile}, args...), &stderr)
+	}
+	exists := func(file string) bool {
+		_, err := os.Stat(filepath.Join(target, file))
+		return err == nil
+	}
+
+	passStages := func() {
+		t.Helper()
+		for _, stage := range deployStages {
+			if err := recordStage(PathBuilder(download), stage, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// check, lint and validate have to pass for the build
+	if err := deploy(); err == nil || !strings.Contains(err.Error(), "check (not run for this build)") {
+		t.Fatalf("deploy error = %v, want refusal", err)
+	}
+	passStages()
+
+	if err := deploy("-dry-run"); err != nil {
+		t.Fatalf("deploy -dry-run error = %v", err)
+	}
+	if exists("index.html") {
+		t.Fatalf("dry-run deployed index.html")
+	}
+
+	if err := deploy("web"); err != nil {
+		t.Fatalf("deploy error = %v", err)
+	}
+	if !exists("index.html") || !exists("old.html") {
+		t.Fatalf("files have not been deployed")
+	}
+
+	// next build: old.html is gone, index.html changed
+	manifest, err = loadManifest(PathBuilder(download).Path("build", "manifest.json"), output, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Build = "build-2"
+	if err := manifest.write(filepath.Join(output, "index.html"), []byte("new index"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.save(PathBuilder(download).Path("build", "manifest.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the results of the previous build don't count
+	if err := deploy(); err == nil || !strings.Contains(err.Error(), "lint (not run for this build)") {
+		t.Fatalf("deploy error = %v, want refusal", err)
+	}
+
+	// a failed lint blocks the deployment (unless forced)
+	passStages()
+	if err := recordStage(PathBuilder(download), "lint", errFailed); !errors.Is(err, errFailed) {
+		t.Fatalf("recordStage() = %v, want errFailed", err)
+	}
+	if err := deploy(); err == nil || !strings.Contains(err.Error(), "refusing to deploy") {
+		t.Fatalf("deploy error = %v, want refusal", err)
+	}
+	if err := deploy("-force"); err != nil {
+		t.Fatalf("deploy -force error = %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(target, "index.html"))
+	if string(content) != "new index" || exists("old.html") {
+		t.Fatalf("unexpected deployment: index.html = %q, old.html exists = %v", content, exists("old.html"))
+	}
+
+	if err := deploy("ftp"); err == nil || !strings.Contains(err.Error(), "configured targets: web") {
+		t.Fatalf("deploy ftp error = %v, want unknown target", err)
+	}
+}
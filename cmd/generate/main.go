@@ -2,29 +2,21 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
-	"flag"
 	"fmt"
 	"html/template"
-	"io"
 	"io/fs"
-	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/flopp/go-googlesheetswrapper"
+	"github.com/flopp/parkrun-map/internal/assets"
 	"github.com/flopp/parkrun-map/internal/parkrun"
 	"github.com/flopp/parkrun-map/internal/utils"
-	"golang.org/x/net/html"
 )
 
 type CanonicalUrl struct {
@@ -33,28 +25,33 @@ type CanonicalUrl struct {
 }
 
 type RenderData struct {
-	Config            *Config
-	Event             *parkrun.Event
-	Events            []*parkrun.Event
-	PlannedDataTermin []PlannedData
-	PlannedDataTest   []PlannedData
-	PlannedDataOther  []PlannedData
-	Article           *Article
-	Articles          []*Article
-	ActiveEvents      int
-	PlannedEvents     int
-	ArchivedEvents    int
-	UmamiJsFile       string
-	JsFiles           []string
-	CssFiles          []string
-	Title             string
-	Description       string
-	Canonical         string
-	Nav               string
-	Timestamp         string
-	Updated           string
-	CanonicalUrls     []CanonicalUrl
-	NoRewrite         bool
+	Config         *Config
+	Event          *parkrun.Event
+	Events         []*parkrun.Event
+	Planned        []*parkrun.PlannedEntry
+	PlannedHistory []*parkrun.PlannedRecord
+	RunDay         *parkrun.RunDay
+	now            time.Time
+	specialDays    *parkrun.SpecialDays
+	Article        *Article
+	Articles       []*Article
+	ActiveEvents   int
+	PlannedEvents  int
+	ArchivedEvents int
+	UmamiJsFile    string
+	JsFiles        []*assets.Asset
+	CssFiles       []*assets.Asset
+	Title          string
+	Description    string
+	Canonical      string
+	Nav            string
+	Timestamp      string
+	Updated        string
+	CanonicalUrls  []CanonicalUrl
+	NoRewrite      bool
+	manifest       *buildManifest
+	assets         *assets.Pipeline
+	minify         bool
 }
 
 func (data *RenderData) set(title, description, canonical, updated string, nav string) {
@@ -73,15 +70,97 @@ func (data *RenderData) eventPath(eventID string) string {
 	return "/" + eventID
 }
 
+func (data *RenderData) eventCanonical(eventID string) string {
+	return fmt.Sprintf("https://%s%s", data.Config.Domain, data.eventPath(eventID))
+}
+
+// SpecialRuns returns the upcoming special days with (possible) runs of the current event.
+func (data *RenderData) SpecialRuns() []parkrun.SpecialRun {
+	if data.Event == nil {
+		return nil
+	}
+	return data.Event.UpcomingSpecialRuns(data.specialDays, data.now)
+}
+
+// CancelledEvents returns the events with upcoming cancellations, ordered by the date of the next cancellation.
+func (data *RenderData) CancelledEvents() []*parkrun.Event {
+	cancelled := make([]*parkrun.Event, 0)
+	for _, event := range data.Events {
+		if event.NextCancellation() != nil {
+			cancelled = append(cancelled, event)
+		}
+	}
+	sort.SliceStable(cancelled, func(i, j int) bool {
+		return cancelled[i].NextCancellation().Date.Before(cancelled[j].NextCancellation().Date)
+	})
+	return cancelled
+}
+
+func (data *RenderData) plannedWithStatus(match func(status parkrun.PlannedStatus) bool) []*parkrun.PlannedEntry {
+	entries := make([]*parkrun.PlannedEntry, 0)
+	for _, entry := range data.Planned {
+		if match(entry.Status) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// PlannedDataTermin returns the planned parkruns with a scheduled first run (used by the "geplante-parkruns" article).
+func (data *RenderData) PlannedDataTermin() []*parkrun.PlannedEntry {
+	return data.plannedWithStatus(func(status parkrun.PlannedStatus) bool {
+		return status == parkrun.PlannedStatusScheduled
+	})
+}
+
+// PlannedDataTest returns the planned parkruns with test runs (used by the "geplante-parkruns" article).
+func (data *RenderData) PlannedDataTest() []*parkrun.PlannedEntry {
+	return data.plannedWithStatus(func(status parkrun.PlannedStatus) bool {
+		return status == parkrun.PlannedStatusTest
+	})
+}
+
+// PlannedDataOther returns the remaining planned parkruns (used by the "geplante-parkruns" article).
+func (data *RenderData) PlannedDataOther() []*parkrun.PlannedEntry {
+	return data.plannedWithStatus(func(status parkrun.PlannedStatus) bool {
+		return status != parkrun.PlannedStatusScheduled && status != parkrun.PlannedStatusTest
+	})
+}
+
+func (data *RenderData) EventJsonLd() (template.JS, error) {
+	return data.Event.JsonLd(data.Canonical)
+}
+
+func (data *RenderData) EventListJsonLd() (template.JS, error) {
+	return parkrun.EventListJsonLd(data.Events, func(event *parkrun.Event) string {
+		return data.eventCanonical(event.Id)
+	})
+}
+
 func (data *RenderData) TemplateStr(templateContent string) (t *template.Template, err error) {
 	return template.New("t").Funcs(template.FuncMap{
 		"EventPath": data.eventPath,
+		"AssetPath": data.assetPath,
 	}).Parse(templateContent)
 }
 
+// assetPath returns the URL path of the asset with the logical name (e.g. "favicon.svg"); without asset pipeline
+// (e.g. in tests), it's the logical name.
+func (data *RenderData) assetPath(name string) (string, error) {
+	if data.assets == nil {
+		return "/" + name, nil
+	}
+	asset, err := data.assets.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return asset.Path(), nil
+}
+
 func (data *RenderData) Template(templateFiles ...string) (t *template.Template, err error) {
 	return template.New("t").Funcs(template.FuncMap{
 		"EventPath": data.eventPath,
+		"AssetPath": data.assetPath,
 	}).ParseFiles(templateFiles...)
 }
 
@@ -93,34 +172,48 @@ func (data *RenderData) render(outputFile string, templateFiles ...string) error
 		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(outputFile), 0770); err != nil {
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, filepath.Base(templateFiles[0]), data); err != nil {
 		return err
 	}
-
-	f, err := os.Create(outputFile)
-	if err != nil {
-		return err
+	content := buf.Bytes()
+	if data.minify {
+		content = assets.MinifyHTML(content)
 	}
-	defer f.Close()
-
-	if err = tmpl.ExecuteTemplate(f, filepath.Base(templateFiles[0]), data); err != nil {
+	updated, _ := time.Parse("2006-01-02", data.Updated)
+	if err := data.writeOutput(outputFile, content, updated); err != nil {
 		return err
 	}
 
 	// only collect "real" pages for sitemap
 	if !strings.HasSuffix(data.Canonical, "404.html") {
-		data.CanonicalUrls = append(data.CanonicalUrls, CanonicalUrl{Url: data.Canonical, Updated: data.Updated})
+		data.CanonicalUrls = append(data.CanonicalUrls, CanonicalUrl{Url: data.Canonical, Updated: data.lastModified(outputFile)})
 	}
 
 	return nil
 }
 
-func (data RenderData) writeSitemap(filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		return err
+// writeOutput writes a file of the output directory; with a build manifest, unchanged files are not rewritten.
+// updated is the date of the content for files that are new to the manifest (zero: now).
+func (data *RenderData) writeOutput(filePath string, content []byte, updated time.Time) error {
+	if data.manifest == nil {
+		return utils.WriteFile(filePath, content)
 	}
-	defer f.Close()
+	return data.manifest.write(filePath, content, updated)
+}
+
+// lastModified returns the date of the last content change of the output file (for the sitemap); without a build manifest,
+// the "Updated" date of the page is used.
+func (data *RenderData) lastModified(filePath string) string {
+	if data.manifest == nil {
+		return data.Updated
+	}
+	return formatDate(data.manifest.updated(filePath))
+}
+
+func (data RenderData) writeSitemap(filePath string) error {
+	var f bytes.Buffer
+	var err error
 
 	type sitemapURL struct {
 		Loc     string `xml:"loc"`
@@ -142,7 +235,7 @@ func (data RenderData) writeSitemap(filePath string) error {
 		return err
 	}
 
-	encoder := xml.NewEncoder(f)
+	encoder := xml.NewEncoder(&f)
 	encoder.Indent("", "  ")
 	if err := encoder.Encode(sitemapURLSet{
 		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
@@ -155,15 +248,12 @@ func (data RenderData) writeSitemap(filePath string) error {
 		return err
 	}
 
-	return nil
+	return data.writeOutput(filePath, f.Bytes(), time.Time{})
 }
 
 func (data RenderData) writeHtaccess(filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	var f bytes.Buffer
+	var err error
 
 	if _, err = f.WriteString("RewriteEngine On\n"); err != nil {
 		return err
@@ -173,6 +263,10 @@ func (data RenderData) writeHtaccess(filePath string) error {
 		return err
 	}
 
+	if _, err = f.WriteString("AddType text/calendar .ics\n"); err != nil {
+		return err
+	}
+
 	// redirect all www requests to non-www
 	if _, err = f.WriteString("\n"); err != nil {
 		return err
@@ -205,15 +299,12 @@ func (data RenderData) writeHtaccess(filePath string) error {
 		}
 	}
 
-	return nil
+	return data.writeOutput(filePath, f.Bytes(), time.Time{})
 }
 
 func (data RenderData) writeMarkdownList(filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	var f bytes.Buffer
+	var err error
 
 	// create a markdown list with all parkruns (+ links to the detail pages)
 	for _, event := range data.Events {
@@ -231,15 +322,11 @@ func (data RenderData) writeMarkdownList(filePath string) error {
 		}
 	}
 
-	return nil
+	return data.writeOutput(filePath, f.Bytes(), time.Time{})
 }
 
 func (data RenderData) writeRobotsTxt(filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	var f bytes.Buffer
 
 	if _, err := f.WriteString("User-agent: *\n"); err != nil {
 		return err
@@ -253,15 +340,11 @@ func (data RenderData) writeRobotsTxt(filePath string) error {
 		return err
 	}
 
-	return nil
+	return data.writeOutput(filePath, f.Bytes(), time.Time{})
 }
 
 func (data RenderData) writeLLMSTxt(filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	var f bytes.Buffer
 
 	info := "Website: " + data.Config.Domain + "\n" +
 		"\n" +
@@ -270,11 +353,16 @@ func (data RenderData) writeLLMSTxt(filePath string) error {
 		"Site structure:\n" +
 		"- / (index.html): Map overview of all parkruns\n" +
 		"- /liste.html: List of all parkruns with details\n" +
+		"- /geplant.html: Timeline of planned parkruns (announcement and expected start)\n" +
+		"- /samstag.html: Which parkruns take place on the coming Saturday (or special day), with cancellations and nearby alternatives\n" +
 		"- /info.html: General information\n" +
 		"- /articles/: Informative articles about parkrun-related topics\n" +
 		"- /datenschutz.html: Privacy policy\n" +
 		"- /impressum.html: Legal notice\n" +
-		"- /[event-id].html: Detail page for each parkrun location\n"
+		"- /[event-id].html: Detail page for each parkrun location\n" +
+		"- /[event-id].ics: Calendar (iCalendar) with weekly runs and cancellations for each parkrun location\n" +
+		"- /alle.ics: Calendar (iCalendar) with all parkrun locations\n" +
+		"- /feed.xml: Atom feed with new parkruns, status changes, cancellations and new articles\n"
 
 	for _, article := range data.Articles {
 		info += "- /articles/" + article.Slug + ".html: " + article.Title + "\n"
@@ -297,7 +385,7 @@ func (data RenderData) writeLLMSTxt(filePath string) error {
 	if _, err := f.WriteString(info); err != nil {
 		return err
 	}
-	return nil
+	return data.writeOutput(filePath, f.Bytes(), time.Time{})
 }
 
 type PathBuilder string
@@ -310,23 +398,6 @@ func (p PathBuilder) Path(items ...string) string {
 	return joined
 }
 
-func randomDuration(min, max time.Duration) time.Duration {
-	delta := max - min
-	return min + time.Duration(rand.Int63n(int64(delta+1)))
-}
-
-func mustCreateIndexNow(indexnow string, outputDir string) {
-	indexNowFile := filepath.Join(outputDir, fmt.Sprintf("%s.txt", indexnow))
-	if err := os.WriteFile(indexNowFile, []byte(indexnow), 0644); err != nil {
-		panic(fmt.Errorf("while writing indexnow file %s: %w", indexNowFile, err))
-	}
-}
-
-type GoogleConfig struct {
-	ApiKey   string `json:"ApiKey"`
-	SheetsId string `json:"SheetsId"`
-}
-
 type Article struct {
 	Slug      string        `json:"slug"`
 	Title     string        `json:"title"`
@@ -432,31 +503,18 @@ func loadArticles(articlesDir string) ([]*Article, error) {
 	return articles, nil
 }
 
+// copyFile copies src to dst, unless dst already has the same content.
 func copyFile(src, dst string) error {
-	if err := os.MkdirAll(filepath.Dir(dst), 0770); err != nil {
-		return err
-	}
-
-	in, err := os.Open(src)
+	content, err := os.ReadFile(src)
 	if err != nil {
 		return err
 	}
-	defer in.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, in); err != nil {
-		return err
-	}
-
-	return nil
+	_, err = utils.WriteFileIfChanged(dst, content)
+	return err
 }
 
-func copyArticleAssets(srcDir, dstDir string) error {
+// copyArticleAssets copies the assets of an article (except meta.json); record is called for every copied file.
+func copyArticleAssets(srcDir, dstDir string, record func(dst string) error) error {
 	if !utils.FileExists(srcDir) {
 		return nil
 	}
@@ -477,915 +535,18 @@ func copyArticleAssets(srcDir, dstDir string) error {
 			return err
 		}
 		dstFile := filepath.Join(dstDir, rel)
-		return copyFile(path, dstFile)
-	})
-}
-
-type Config struct {
-	Domain         string       `json:"domain"`
-	IndexNow       string       `json:"indexnow"`
-	Google         GoogleConfig `json:"google"`
-	UmamiWebsiteID string       `json:"UmamiWebsiteID"`
-}
-
-func unmarshalConfig(content []byte, config *Config) error {
-	if err := json.Unmarshal(content, config); err != nil {
-		return fmt.Errorf("while unmarshalling config: %w", err)
-	}
-	return nil
-}
-
-func val(cols map[string]int, row []string, name string) string {
-	idx, found := cols[name]
-	if !found {
-		panic(fmt.Sprintf("column %s not found", name))
-	}
-	if idx >= len(row) {
-		return ""
-	}
-	return row[idx]
-}
-
-type PlannedData struct {
-	Name      string
-	City      string
-	State     string
-	Status    string
-	Added     string
-	Start     string
-	Instagram string
-	Link1     parkrun.Link
-}
-
-func loadGoogleSheetsData(apiKey, sheetsId string) (map[string]*parkrun.ParkrunInfo, []PlannedData, error) {
-	ctx := context.Background()
-	client, err := googlesheetswrapper.New(apiKey, sheetsId)
-	if err != nil {
-		return nil, nil, fmt.Errorf("creating sheets client: %w", err)
-	}
-	allSheets, err := client.ReadAll(ctx)
-	if err != nil {
-		return nil, nil, fmt.Errorf("reading all sheets: %w", err)
-	}
-
-	sheet, found := allSheets["data"]
-	if !found {
-		return nil, nil, fmt.Errorf("sheet 'data' not found")
-	}
-
-	// parse & validate columns
-	columns, err := googlesheetswrapper.ExtractHeader(sheet, []string{
-		"id", "updated", "name", "city", "state", "location", "description", "status", "first", "coordinates",
-		"route_type", "google_route_id", "google_maps_url",
-		"instagram", "facebook", "strava-club", "strava-segment",
-		"link1", "link2", "link3", "link4", "link5"},
-		false)
-	if err != nil {
-		return nil, nil, fmt.Errorf("extracting header: %w", err)
-	}
-
-	parkrunInfos := make(map[string]*parkrun.ParkrunInfo)
-	for i, row := range sheet[1:] {
-		id := val(columns, row, "id")
-		updatedStr := val(columns, row, "updated")
-		updated, err := time.Parse("2006-01-02", updatedStr)
-		if err != nil {
-			return nil, nil, fmt.Errorf("parsing updated date in row %d: %w", i+2, err)
-		}
-		name := val(columns, row, "name")
-		city := val(columns, row, "city")
-		state := val(columns, row, "state")
-		location := val(columns, row, "location")
-		description := val(columns, row, "description")
-		routeType := val(columns, row, "route_type")
-		routeId := val(columns, row, "google_route_id")
-		googleMaps := val(columns, row, "google_maps_url")
-		first := val(columns, row, "first")
-		if first == "?" {
-			first = "-"
-		}
-		status := val(columns, row, "status")
-		coordinates := val(columns, row, "coordinates")
-		instagram := val(columns, row, "instagram")
-		facebook := val(columns, row, "facebook")
-		stravaClub := val(columns, row, "strava-club")
-		stravaSegment := val(columns, row, "strava-segment")
-
-		links := make([]parkrun.Link, 0)
-		if instagram != "" {
-			links = append(links, parkrun.Link{
-				Name: "Instagram",
-				Url:  instagram,
-			})
-		}
-		if facebook != "" {
-			links = append(links, parkrun.Link{
-				Name: "Facebook",
-				Url:  facebook,
-			})
-		}
-		if stravaClub != "" {
-			links = append(links, parkrun.Link{
-				Name: "Strava Club",
-				Url:  stravaClub,
-			})
-		}
-		if stravaSegment != "" {
-			links = append(links, parkrun.Link{
-				Name: "Strava Segment",
-				Url:  stravaSegment,
-			})
-		}
-		for j := 1; j <= 5; j++ {
-			linkStr := val(columns, row, fmt.Sprintf("link%d", j))
-			//log.Printf("row %d link%d: %s", i+2, j, linkStr)
-			if linkStr != "" {
-				link, err := parkrun.ParseLink(linkStr)
-				if err != nil {
-					return nil, nil, fmt.Errorf("parsing link in row %d: %w", i+2, err)
-				}
-				links = append(links, link)
-			}
-		}
-		//log.Printf("links: %d\n", len(links))
-
-		parkrunInfos[id] = &parkrun.ParkrunInfo{
-			Id:          id,
-			Name:        name,
-			City:        city,
-			State:       state,
-			Location:    location,
-			Description: description,
-			RouteType:   routeType,
-			RouteID:     routeId,
-			GoogleMaps:  googleMaps,
-			Updated:     updated,
-			First:       first,
-			Status:      status,
-			Coordinates: coordinates,
-			Links:       links,
-		}
-	}
-
-	plannedData := make([]PlannedData, 0)
-
-	plannedSheet, found := allSheets["planned"]
-	if !found {
-		return nil, nil, fmt.Errorf("sheet 'planned' not found")
-	}
-	plannedColumns, err := googlesheetswrapper.ExtractHeader(plannedSheet, []string{"name", "city", "state", "status", "added", "start", "instagram", "link1"}, false)
-	if err != nil {
-		return nil, nil, fmt.Errorf("extracting header from planned sheet: %w", err)
-	}
-
-	for _, row := range plannedSheet[1:] {
-		name := val(plannedColumns, row, "name")
-		city := val(plannedColumns, row, "city")
-		state := val(plannedColumns, row, "state")
-		status := val(plannedColumns, row, "status")
-		added := val(plannedColumns, row, "added")
-		start := val(plannedColumns, row, "start")
-		instagram := val(plannedColumns, row, "instagram")
-		link1, err := parkrun.ParseLink(val(plannedColumns, row, "link1"))
-		if err != nil {
-			return nil, nil, fmt.Errorf("parsing link1 in planned sheet: %w", err)
-		}
-		plannedData = append(plannedData, PlannedData{
-			Name:      name,
-			City:      city,
-			State:     state,
-			Status:    status,
-			Added:     added,
-			Start:     start,
-			Instagram: instagram,
-			Link1:     link1,
-		})
-	}
-
-	return parkrunInfos, plannedData, nil
-}
-
-type SummaryData struct {
-	LatestEventId int
-	Runners       int
-	Volunteers    int
-}
-
-func parse_summary_wiki(filePath string) (map[string]SummaryData, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("while reading summary wiki file: %w", err)
-	}
-
-	summaryData := make(map[string]SummaryData)
-
-	// parse the HTML file
-	// 1. find the table
-	//    <table class="wikitable sortable">
-	// 2. parse the table rows
-	// 3. extract columns 0 (event name), column 4 (runners), column 7 (volunteers), column 10 (latest event id)
-	// 4. put the data in a map[event name]SummaryData
-	//
-	// examples of the columns:
-	//
-	// the event name column looks like this:
-	//    <td><a href="/index.php/Allerpark_parkrun" title="Allerpark parkrun">Allerpark</a>
-	//    </td>
-	//   -> we want to extcat the title attribute ("Allerpark parkrun"), this is the event name
-	// the other columns look like this:
-	//    <td>48
-	//    </td>
-	//   -> just extract the content ("48") and parse it as int
-	//
-	doc, err := html.Parse(strings.NewReader(string(content)))
-	if err != nil {
-		return nil, fmt.Errorf("while parsing HTML: %w", err)
-	}
-
-	var parseTable func(*html.Node)
-	parseTable = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "table" {
-			// Found the table, now parse rows
-			var parseRows func(*html.Node)
-			parseRows = func(node *html.Node) {
-				if node.Type == html.ElementNode && node.Data == "tr" {
-					// Parse table row
-					var cols []*html.Node
-					for child := node.FirstChild; child != nil; child = child.NextSibling {
-						if child.Type == html.ElementNode && (child.Data == "td" || child.Data == "th") {
-							cols = append(cols, child)
-						}
-					}
-
-					if len(cols) >= 11 {
-						// Extract event name from column 0
-						eventName := extractTitleAttribute(cols[0])
-						if eventName == "" {
-							return
-						}
-
-						// Extract runners from column 4
-						runners := extractIntContent(cols[4])
-
-						// Extract volunteers from column 7
-						volunteers := extractIntContent(cols[7])
-
-						// Extract latest event id from column 10
-						latestEventId := extractIntContent(cols[10])
-
-						summaryData[eventName] = SummaryData{
-							LatestEventId: latestEventId,
-							Runners:       runners,
-							Volunteers:    volunteers,
-						}
-					}
-				}
-
-				for child := node.FirstChild; child != nil; child = child.NextSibling {
-					parseRows(child)
-				}
-			}
-
-			parseRows(n)
-			return
-		}
-
-		for child := n.FirstChild; child != nil; child = child.NextSibling {
-			parseTable(child)
-		}
-	}
-
-	parseTable(doc)
-
-	return summaryData, nil
-}
-
-func extractTitleAttribute(n *html.Node) string {
-	var walk func(*html.Node) string
-	walk = func(node *html.Node) string {
-		if node.Type == html.ElementNode && node.Data == "a" {
-			for _, attr := range node.Attr {
-				if attr.Key == "title" {
-					return strings.TrimSpace(attr.Val)
-				}
-			}
-		}
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			if title := walk(child); title != "" {
-				return title
-			}
-		}
-		return ""
-	}
-
-	return walk(n)
-}
-
-func extractIntContent(n *html.Node) int {
-	var sb strings.Builder
-	var walk func(*html.Node)
-	walk = func(node *html.Node) {
-		if node.Type == html.TextNode {
-			sb.WriteString(node.Data)
-		}
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			walk(child)
-		}
-	}
-
-	walk(n)
-	text := strings.TrimSpace(sb.String())
-	if text == "" {
-		return 0
-	}
-
-	var digits strings.Builder
-	for _, r := range text {
-		if r >= '0' && r <= '9' {
-			digits.WriteRune(r)
+		if err := copyFile(path, dstFile); err != nil {
+			return err
 		}
-	}
-
-	if digits.Len() == 0 {
-		return 0
-	}
-
-	v, err := strconv.Atoi(digits.String())
-	if err != nil {
-		return 0
-	}
-	return v
+		return record(dstFile)
+	})
 }
 
 func main() {
-	dataDir := flag.String("data", "data", "the data directory")
-	downloadDir := flag.String("download", ".download", "the download directory")
-	outputDir := flag.String("output", ".output", "the output directory")
-	configFile := flag.String("config", "config.json", "the config file with Google API key and Sheets ID")
-	exportCsvFile := flag.String("export-csv", "", "export CSV file with all parkrun events")
-	disableUmami := flag.Bool("disable-umami", false, "disable Umami analytics in generated output")
-	noRewrite := flag.Bool("no-rewrite", false, "disable URL rewrite rules in generated output")
-	verbose := flag.Bool("verbose", false, "verbose logging")
-	check := flag.Bool("check", false, "check mode")
-	flag.Parse()
-
-	if !*verbose {
-		log.SetOutput(io.Discard)
-	}
-
-	now := time.Now()
-	fileAge1h := now.Add(-1 * time.Hour)
-	fileAge1d := now.Add(-24 * time.Hour)
-	fileAge1w := now.Add(-24 * 7 * time.Hour)
-
-	// Saturday, October 3rd, January 1st (German special days)
-	isSaturday := now.Weekday() == time.Saturday
-	isOctober3rd := now.Day() == 3 && now.Month() == time.October
-	isJanuary1st := now.Day() == 1 && now.Month() == time.January
-	isParkrunDay := (isSaturday || isOctober3rd || isJanuary1st) && now.Hour() >= 10
-
-	utils.SetDownloadDelay(2 * time.Second)
-
-	data := PathBuilder(*dataDir)
-	download := PathBuilder(*downloadDir)
-	output := PathBuilder(*outputDir)
-	articles, err := loadArticles(data.Path("articles"))
-	if err != nil {
-		panic(fmt.Errorf("while loading articles: %w", err))
-	}
-
-	// fetch data from Google Sheets
-	var parkrun_infos map[string]*parkrun.ParkrunInfo
-	var plannedDataTermin []PlannedData
-	var plannedDataTest []PlannedData
-	var plannedDataOther []PlannedData
-	var config Config
-	if configContent, err := os.ReadFile(*configFile); err != nil {
-		panic(fmt.Errorf("while reading config file %s: %v", *configFile, err))
-	} else if err := unmarshalConfig(configContent, &config); err != nil {
-		panic(fmt.Errorf("while parsing config file %s: %v", *configFile, err))
-	} else if googleInfos, planned, err := loadGoogleSheetsData(config.Google.ApiKey, config.Google.SheetsId); err != nil {
-		panic(fmt.Errorf("while loading Google Sheets data: %v", err))
-	} else {
-		parkrun_infos = googleInfos
-		for _, p := range planned {
-			if p.Status == "termin" {
-				plannedDataTermin = append(plannedDataTermin, p)
-			} else if p.Status == "test" {
-				plannedDataTest = append(plannedDataTest, p)
-			} else {
-				plannedDataOther = append(plannedDataOther, p)
-			}
-		}
-	}
-	if *disableUmami {
-		config.UmamiWebsiteID = ""
-	}
-
-	// fetch parkrun events
-	events_json_url := "https://images.parkrun.com/events.json"
-	events_json_file := download.Path("parkrun", "events.json.gz")
-	if err := utils.DownloadFileIfOlder(events_json_url, events_json_file, fileAge1d); err != nil {
-		panic(fmt.Errorf("while downloading %s to %s: %w", events_json_url, events_json_file, err))
-	}
-
-	// parse parkrun events (only returns German events!)
-	events, err := parkrun.LoadEvents(events_json_file, parkrun_infos, true /* germanOnly */)
-	if err != nil {
-		panic(fmt.Errorf("loading parkrun events: %w", err))
-	}
-
-	// check mode
-	if *check {
-		log.Printf("CHECKING COURSES")
-
-		for _, event := range events {
-			log.Printf("    CHECKING %s", event.Id)
-
-			// check whether the route ID from Google Sheets matches the route ID from the parkrun route page
-			course_url := event.CoursePageUrl()
-			course_file := download.Path("parkrun", event.Id, "course_page")
-			utils.MustDownloadFileIfOlder(course_url, course_file, fileAge1w)
-			content, err := os.ReadFile(course_file)
-			if err != nil {
-				log.Printf("  ERROR reading course page file: %v", err)
-				continue
-			}
-			strContent := string(content)
-			routeLink := ""
-			// use regex to extract get the full google maps link
-			// e.g. 'https://www.google.com/maps/d/embed?mid=1P8MeMOlLX_4sh9iiES6auGwuNE1tgYo&ehbc=2E312F&noprof=1'
-			routeLinkRe := `iframe src="(https://www\.google\.com/maps/[^"]+)"`
-			re := regexp.MustCompile(routeLinkRe)
-			matches := re.FindStringSubmatch(strContent)
-			if len(matches) == 2 {
-				routeLink = matches[1]
-			} else {
-				log.Fatalf("  ERROR extracting route link from course page, iframe regex did not match")
-			}
-
-			// extract "mid" parameter from the route link
-			routeId := ""
-			routeIdRe := `mid=([^&]+)`
-			re = regexp.MustCompile(routeIdRe)
-			matches = re.FindStringSubmatch(routeLink)
-			if len(matches) == 2 {
-				routeId = matches[1]
-			} else {
-				log.Fatalf("  ERROR extracting route ID from route link, regex did not match")
-			}
-
-			// check if the route ID from the course page matches the route ID from Google Sheets
-			if event.GoogleMapsCourseId() != routeId {
-				log.Fatalf("  ERROR route ID from course page does not match route ID from Google Sheets!\n    course page route ID: %s\n    Google Sheets route ID: %s\n", routeId, event.GoogleMapsCourseId())
-			}
-
-			// check distance between coordinates from Google Sheets and coordinates from the course
-			kml_url := event.GoogleMapsCourseKmlUrl()
-			kml_file := download.Path("parkrun", event.Id, event.GoogleMapsCourseId())
-			utils.MustDownloadFileIfOlder(kml_url, kml_file, now.Add(randomDuration(-24*200*time.Hour, -24*100*time.Hour)))
-
-			if err := event.LoadKML(kml_file); err != nil {
-				panic(fmt.Errorf("file parsing %s: %w", kml_file, err))
-			}
-
-			if !event.CoordsFromKml.IsValid() {
-				log.Fatalf("  ERROR coordinates from KML are not valid, cannot check distance to Google Sheets coordinates")
-			} else if !event.Coords.IsValid() {
-				log.Fatalf("  ERROR coordinates from Google Sheets are not valid, cannot check distance to KML coordinates")
-			} else {
-				distance := utils.DistanceMeters(event.Coords, event.CoordsFromKml)
-				if distance > 10 {
-					log.Fatalf("  ERROR distance between Google Sheets coordinates and KML coordinates is greater than 20m: %f meters\n%f,%f", distance, event.CoordsFromKml.Lat, event.CoordsFromKml.Lon)
-				}
-			}
-		}
-
-		log.Printf("CHECKING LINKS")
-
-		for _, event := range events {
-			log.Printf("    CHECKING %s", event.Id)
-
-			// check if all links are valid
-			for _, link := range event.Links() {
-				if strings.Contains(link.Url, "facebook") {
-					// skip facebook links, they often have issues with bots and it's not critical if they are correct
-					continue
-				}
-				if err := utils.CheckLink(link.Url); err != nil {
-					log.Printf("  ERROR checking link %s %s: %v", link.Name, link.Url, err)
-				}
-			}
-
-		}
-		return
-	}
-
-	// summary wiki file
-	summary_wiki_url := "https://wiki.parkrun.com/index.php/Summary_Statistics_By_Event/Germany"
-	summary_file := download.Path("parkrun", "summary_wiki")
-	if err := utils.DownloadFileIfOlder(summary_wiki_url, summary_file, fileAge1d); err != nil {
-		panic(fmt.Errorf("while downloading %s to %s: %w", summary_wiki_url, summary_file, err))
-	}
-	summary_data, err := parse_summary_wiki(summary_file)
-	if err != nil {
-		panic(fmt.Errorf("while parsing summary wiki file %s: %w", summary_file, err))
-	}
-	for eventName, data := range summary_data {
-		log.Printf("summary data: %s: latest event id=%d runners=%d volunteers=%d", eventName, data.LatestEventId, data.Runners, data.Volunteers)
-	}
-
-	is_latest := make(map[string]struct{})
-	latestDate := time.Time{}
-	dates := make(map[*parkrun.Event]time.Time)
-	for _, event := range events {
-		if !event.Archived() && !event.TemporarilyClosed() {
-			wiki_file := download.Path("parkrun", event.Id, "wiki")
-			if utils.FileExists(wiki_file) {
-				if err := event.LoadWiki(wiki_file); err == nil && event.LatestRun != nil {
-					if event.LatestRun.Date.After(latestDate) {
-						latestDate = event.LatestRun.Date
-					}
-					if summary, found := summary_data[event.Name]; found {
-						if event.LatestRun.Index == summary.LatestEventId && event.LatestRun.RunnerCount == summary.Runners {
-							is_latest[event.Name] = struct{}{}
-						}
-					} else {
-						log.Printf("no summary data found for event %s", event.Name)
-					}
-
-					dates[event] = event.LatestRun.Date
-					// force download if there's something wrong with the numbers
-					if event.LatestRun.RunnerCount == 0 {
-						dates[event] = time.Time{}
-					}
-				} else if err != nil {
-					if err := os.Remove(wiki_file); err != nil {
-						panic(err)
-					}
-				}
-			}
-		}
-	}
-	log.Printf("lastest existing date: %v", latestDate)
-
-	// Pull latest results, force update for all events that are definitely outdated
-	for _, event := range events {
-		isOutdated := false
-		if !event.Archived() && !event.TemporarilyClosed() {
-			if date, found := dates[event]; found && (latestDate.After(date) || (isParkrunDay && date.Format("2006-01-02") != now.Format("2006-01-02"))) {
-				log.Printf("%s: outdated! date=%v latestafter=%v parkrunday=%v notatparkrunday=%v", event.Id, date, latestDate.After(date), isParkrunDay, date.Format("2006-01-02") != now.Format("2006-01-02"))
-				isOutdated = true
-			}
-			if event.Planned() && isParkrunDay {
-				log.Printf("%s: outdated! planned & parkrunday", event.Id)
-				isOutdated = true
-			}
-		}
-		if !event.Active() {
-			continue
-		}
-		wiki_url := event.WikiUrl()
-		wiki_file := download.Path("parkrun", event.Id, "wiki")
-		if _, found := is_latest[event.Name]; found {
-			log.Printf("%s: is latest according to summary wiki, no update", event.Id)
-		} else if isOutdated {
-			if event.Planned() {
-				// downloading planned events can fail without problems, so we don't force it and just log errors
-				if err := utils.DownloadFileIfOlder(wiki_url, wiki_file, fileAge1h); err != nil {
-					log.Printf("while downloading planned event %s to %s: %v", wiki_url, wiki_file, err)
-				}
-			} else {
-				utils.MustDownloadFileIfOlder(wiki_url, wiki_file, fileAge1h)
-			}
-		} else if event.Planned() {
-			// downloading planned events can fail without problems, so we don't force it and just log errors
-			if err := utils.DownloadFileIfOlder(wiki_url, wiki_file, fileAge1h); err != nil {
-				log.Printf("while downloading planned event %s to %s: %v", wiki_url, wiki_file, err)
-			}
-		} else {
-			utils.MustDownloadFileIfOlder(wiki_url, wiki_file, fileAge1d)
-		}
-		if err := event.LoadWiki(wiki_file); err != nil {
-			log.Printf("while parsing %s: %v", wiki_file, err)
-		} else if event.LatestRun != nil && event.LatestRun.Date.After(latestDate) {
-			latestDate = event.LatestRun.Date
-		}
-	}
-
-	// cancellations
-	// read & parse
-	cancellations_wiki_url := "https://wiki.parkrun.com/index.php/Cancellations/Germany"
-	cancellations_file := download.Path("parkrun", "cancellations_wiki")
-	if err := utils.DownloadFileIfOlder(cancellations_wiki_url, cancellations_file, fileAge1d); err != nil {
-		panic(fmt.Errorf("while downloading %s to %s: %w", cancellations_wiki_url, cancellations_file, err))
-	}
-	cancellations_data, err := parkrun.ParseCancellationsWiki(cancellations_file)
-	if err != nil {
-		panic(fmt.Errorf("while parsing cancellations wiki file %s: %w", cancellations_file, err))
-	}
-	processedCancellations := make(map[string]bool)
-	for eventName := range cancellations_data {
-		processedCancellations[eventName] = false
-	}
-	// apply
-	for _, event := range events {
-		if cancellations, found := cancellations_data[event.Name]; found {
-			log.Printf("applying cancellation data from wiki for event %s: %d cancellations", event.Name, len(cancellations))
-			event.Cancellations = cancellations
-			processedCancellations[event.Name] = true
-		}
-	}
-	// check for cancellations that were not applied (e.g. because the event name from the wiki does not match the event name from the parkrun data)
-	for eventName, processed := range processedCancellations {
-		if !processed {
-			log.Printf("cancellation data from wiki not used for event %s", eventName)
-		}
-	}
-
-	for _, event := range events {
-		event.Current = !event.Archived() && !event.TemporarilyClosed() && event.LatestRun != nil && event.LatestRun.Date.Equal(latestDate)
-	}
-
-	// Determine order
-	orderedEvents := make([]*parkrun.Event, 0, len(events))
-	for _, event := range events {
-		event.Order = 0
-		if event.Current {
-			orderedEvents = append(orderedEvents, event)
-		}
-	}
-	sort.Slice(orderedEvents, func(i, j int) bool {
-		return orderedEvents[i].LatestRun.RunnerCount > orderedEvents[j].LatestRun.RunnerCount
-	})
-	order := 0
-	orderStep := 1
-	last := 0
-	for _, event := range orderedEvents {
-		if event.LatestRun.RunnerCount != last {
-			order += orderStep
-			last = event.LatestRun.RunnerCount
-			orderStep = 0
-		}
-		orderStep += 1
-		event.Order = order
-	}
-
-	for _, event := range events {
-		kml_url := event.GoogleMapsCourseKmlUrl()
-		kml_file := download.Path("parkrun", event.Id, event.GoogleMapsCourseId())
-		utils.MustDownloadFileIfOlder(kml_url, kml_file, now.Add(randomDuration(-24*200*time.Hour, -24*100*time.Hour)))
-
-		if err := event.LoadKML(kml_file); err != nil {
-			panic(fmt.Errorf("file parsing %s: %w", kml_file, err))
-		}
-	}
-
-	// determine 3 nearby parkruns for each event
-	for _, event := range events {
-		event.PopulateNearby(events)
-	}
-
-	// fetch external assets (bulma, leaflet)
-
-	// renovate: datasource=npm depName=leaflet
-	leaflet_version := "1.9.4"
-
-	leaflet_url := PathBuilder(fmt.Sprintf("https://unpkg.com/leaflet@%s", leaflet_version))
-	picocss_url := PathBuilder("https://cdn.jsdelivr.net/npm/@picocss/pico@2/css")
-
-	// download leaflet
-	utils.MustDownloadFileIfOlder(leaflet_url.Path("dist/leaflet.js"), download.Path("leaflet", "leaflet.js"), fileAge1w)
-	utils.MustDownloadFileIfOlder(leaflet_url.Path("dist/leaflet.css"), download.Path("leaflet", "leaflet.css"), fileAge1w)
-	utils.MustDownloadFileIfOlder(leaflet_url.Path("dist/images/marker-icon.png"), download.Path("leaflet", "marker-icon.png"), fileAge1w)
-	utils.MustDownloadFileIfOlder(leaflet_url.Path("dist/images/marker-icon-2x.png"), download.Path("leaflet", "marker-icon-2x.png"), fileAge1w)
-	utils.MustDownloadFileIfOlder(leaflet_url.Path("dist/images/marker-shadow.png"), download.Path("leaflet", "marker-shadow.png"), fileAge1w)
-
-	// download picocss
-	utils.MustDownloadFileIfOlder(picocss_url.Path("pico.min.css"), download.Path("picocss", "pico.css"), fileAge1w)
-
-	// download umami
-	if config.UmamiWebsiteID != "" {
-		umami_url := "https://cloud.umami.is/script.js"
-		utils.MustDownloadFileIfOlder(umami_url, download.Path("umami", "umami.js"), fileAge1w)
-	}
-
-	// sortable
-	sortable_url := "https://cdn.jsdelivr.net/gh/tofsjonas/sortable@latest/dist"
-	utils.MustDownloadFileIfOlder(sortable_url+"/sortable.min.css", download.Path("sortable", "sortable.min.css"), fileAge1w)
-	utils.MustDownloadFileIfOlder(sortable_url+"/sortable.min.js", download.Path("sortable", "sortable.min.js"), fileAge1w)
-
-	// dowbnload jquery (needed for datatables)
-	jquery_version := "4.0.0"
-	jquery_js_url := fmt.Sprintf("https://code.jquery.com/jquery-%s.min.js", jquery_version)
-	utils.MustDownloadFileIfOlder(jquery_js_url, download.Path("jquery", "jquery.min.js"), fileAge1w)
-
-	// download datatables
-	datatables_version := "2.3.7"
-	datatables_css_url := fmt.Sprintf("https://cdn.datatables.net/%s/css/dataTables.dataTables.min.css", datatables_version)
-	datatables_js_url := fmt.Sprintf("https://cdn.datatables.net/%s/js/dataTables.min.js", datatables_version)
-	utils.MustDownloadFileIfOlder(datatables_css_url, download.Path("datatables", "dataTables.dataTables.min.css"), fileAge1w)
-	utils.MustDownloadFileIfOlder(datatables_js_url, download.Path("datatables", "dataTables.min.js"), fileAge1w)
-	// patch datatables js to fix conflict with Pico CSS (datatables buttons are
-	// styled as Pico CSS buttons which breaks the layout)
-	// -> remove '.attr("role","button")' from datatables js
-	if content, err := os.ReadFile(download.Path("datatables", "dataTables.min.js")); err != nil {
-		panic(fmt.Errorf("while reading datatables js file: %w", err))
-	} else {
-		patchedContent := string(content)
-		patchedContent = strings.ReplaceAll(patchedContent, `.attr("role","button")`, "")
-		if err := os.WriteFile(download.Path("datatables", "dataTables.min.js"), []byte(patchedContent), 0644); err != nil {
-			panic(fmt.Errorf("while writing patched datatables js file: %w", err))
-		}
-	}
-
-	// render data
-	if err := parkrun.RenderJs(events, download.Path("data.js")); err != nil {
-		panic(fmt.Errorf("failed to render data: %v", err))
-	}
-
-	umami_js_file := ""
-	if config.UmamiWebsiteID != "" {
-		umami_js_file = utils.MustCopyHash(download.Path("umami/umami.js"), "umami-HASH.js", *outputDir)
-	}
-
-	js_files := make([]string, 0)
-	js_files = append(js_files, utils.MustCopyHash(download.Path("data.js"), "data-HASH.js", *outputDir))
-	js_files = append(js_files, utils.MustCopyHash(download.Path("leaflet/leaflet.js"), "leaflet-HASH.js", *outputDir))
-	js_files = append(js_files, utils.MustCopyHash(download.Path("sortable/sortable.min.js"), "sortable-HASH.js", *outputDir))
-	js_files = append(js_files, utils.MustCopyHash(data.Path("static", "main.js"), "main-HASH.js", *outputDir))
-
-	css_files := make([]string, 0)
-	css_files = append(css_files, utils.MustCopyHash(download.Path("picocss/pico.css"), "pico-HASH.css", *outputDir))
-	css_files = append(css_files, utils.MustCopyHash(download.Path("leaflet/leaflet.css"), "leaflet-HASH.css", *outputDir))
-	css_files = append(css_files, utils.MustCopyHash(download.Path("sortable/sortable.min.css"), "sortable-HASH.css", *outputDir))
-	css_files = append(css_files, utils.MustCopyHash(data.Path("static", "style.css"), "style-HASH.css", *outputDir))
-
-	utils.MustCopyHash(download.Path("leaflet/marker-icon.png"), "images/marker-icon.png", *outputDir)
-	utils.MustCopyHash(download.Path("leaflet/marker-icon-2x.png"), "images/marker-icon-2x.png", *outputDir)
-	utils.MustCopyHash(download.Path("leaflet/marker-shadow.png"), "images/marker-shadow.png", *outputDir)
-	for _, color := range []string{"red", "green", "grey"} {
-		utils.MustCopyHash(data.Path(fmt.Sprintf("static/marker-%s-icon.png", color)), fmt.Sprintf("images/marker-%s-icon.png", color), *outputDir)
-		utils.MustCopyHash(data.Path(fmt.Sprintf("static/marker-%s-icon-2x.png", color)), fmt.Sprintf("images/marker-%s-icon-2x.png", color), *outputDir)
-	}
-	utils.MustCopyHash(data.Path("static", "favicon.ico"), "favicon.ico", *outputDir)
-	utils.MustCopyHash(data.Path("static", "favicon.svg"), "favicon.svg", *outputDir)
-	mustCreateIndexNow(config.IndexNow, *outputDir)
-
-	// render templates to output folder
-	active := 0
-	planned := 0
-	archived := 0
-	for _, event := range events {
-		if event.Active() {
-			active += 1
-		} else if event.Planned() {
-			planned += 1
-		} else {
-			archived += 1
-		}
-	}
-
-	canonical := func(path string) string {
-		return fmt.Sprintf("https://%s/%s", config.Domain, path)
-	}
-
-	renderData := RenderData{
-		Config:            &config,
-		Event:             nil,
-		Events:            events,
-		PlannedDataTermin: plannedDataTermin,
-		PlannedDataTest:   plannedDataTest,
-		PlannedDataOther:  plannedDataOther,
-		Article:           nil,
-		Articles:          articles,
-		ActiveEvents:      active,
-		PlannedEvents:     planned,
-		ArchivedEvents:    archived,
-		UmamiJsFile:       umami_js_file,
-		JsFiles:           js_files,
-		CssFiles:          css_files,
-		Title:             "",
-		Description:       "",
-		Canonical:         "",
-		Nav:               "",
-		Timestamp:         now.Format("2006-01-02 15:04:05"),
-		NoRewrite:         *noRewrite,
-		CanonicalUrls:     nil,
-	}
-
-	var latestEventUpdate time.Time
-	var latestArticleUpdate time.Time
-	for _, event := range events {
-		u := event.UpdatedAt()
-		if u.After(latestEventUpdate) {
-			latestEventUpdate = u
-		}
-	}
-	for _, article := range articles {
-		u := article.UpdatedAt
-		if u.After(latestArticleUpdate) {
-			latestArticleUpdate = u
-		}
-	}
-	formatDate := func(t time.Time) string {
-		if t.IsZero() {
-			return ""
-		}
-		return t.Format("2006-01-02")
-	}
-
-	t := PathBuilder(filepath.Join(*dataDir, "templates"))
-	renderData.set("Karte mit allen parkrun Standorten in Deutschland", "Alle parkrun Standorte in Deutschland auf einer Karte", canonical(""), formatDate(latestEventUpdate), "map")
-	if err := renderData.render(output.Path("index.html"), t.Path("index.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'index.html': %v", err))
-	}
-	renderData.set("Alle parkrun Standorte in Deutschland", "Alle parkrun Standorte in Deutschland.", canonical("liste.html"), formatDate(latestEventUpdate), "list")
-	if err := renderData.render(output.Path("liste.html"), t.Path("liste.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'list.html': %v", err))
-	}
-	renderData.set("parkruns Karte - Info", "Informationen", canonical("info.html"), "", "info")
-	if err := renderData.render(output.Path("info.html"), t.Path("info.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'info.html': %v", err))
-	}
-	renderData.set("parkrun Artikel", "Informative Artikel rund um parkrun", canonical("articles/"), formatDate(latestArticleUpdate), "articles")
-	if err := renderData.render(output.Path("articles", "index.html"), t.Path("articles.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'articles/index.html': %v", err))
-	}
-	renderData.set("parkruns Karte - Datenschutz", "Datenschutzinformationen", canonical("datenschutz.html"), "", "datenschutz")
-	if err := renderData.render(output.Path("datenschutz.html"), t.Path("datenschutz.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'datenschutz.html': %v", err))
-	}
-	renderData.set("parkruns Karte - Impressum", "Impressum", canonical("impressum.html"), "", "impressum")
-	if err := renderData.render(output.Path("impressum.html"), t.Path("impressum.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering 'impressum.html': %v", err))
-	}
-	renderData.set("404 - Seite nicht gefunden", "Die angeforderte Seite wurde nicht gefunden.", canonical("404.html"), "", "")
-	if err := renderData.render(output.Path("404.html"), t.Path("404.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-		panic(fmt.Errorf("while rendering '404.html': %v", err))
-	}
-
-	for _, event := range events {
-		renderData.Event = event
-		title := fmt.Sprintf("%s, %s", event.FixedName(), event.FixedLocation())
-		description := fmt.Sprintf("Alle Infos zum %s in %s; Strecke, Karte, Statistiken und wichtige Links", event.FixedName(), event.FixedLocation())
-		file := fmt.Sprintf("%s.html", event.Id)
-		canonicalUrl := canonical(file)
-		if !*noRewrite {
-			// remove .html from canonical URL for better SEO
-			canonicalUrl = strings.TrimSuffix(canonicalUrl, ".html")
-		}
-		renderData.set(title, description, canonicalUrl, formatDate(event.UpdatedAt()), "list")
-		if err := renderData.render(output.Path(file), t.Path("parkrun.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-			panic(fmt.Errorf("while rendering '%s': %v", file, err))
-		}
-	}
-
-	for _, article := range articles {
-		contentTemplate, err := renderData.TemplateStr(string(article.Content))
-		if err != nil {
-			panic(fmt.Errorf("while parsing article content template for '%s': %v", article.Slug, err))
-		}
-		var contentBuffer bytes.Buffer
-		if err := contentTemplate.Execute(&contentBuffer, renderData); err != nil {
-			panic(fmt.Errorf("while executing article content template for '%s': %v", article.Slug, err))
-		}
-		article.Content = template.HTML(contentBuffer.String())
-
-		renderData.Article = article
-		renderData.set(article.Title+" - parkrun Artikel", article.Summary, canonical(fmt.Sprintf("articles/%s.html", article.Slug)), formatDate(article.UpdatedAt), "articles")
-		if err := renderData.render(output.Path("articles", fmt.Sprintf("%s.html", article.Slug)), t.Path("article.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
-			panic(fmt.Errorf("while rendering article '%s': %v", article.Slug, err))
-		}
-
-		if err := copyArticleAssets(data.Path("articles", article.Slug), output.Path("articles", article.Slug)); err != nil {
-			panic(fmt.Errorf("while copying assets for article '%s': %w", article.Slug, err))
-		}
-	}
-
-	if err := renderData.writeSitemap(output.Path("sitemap.xml")); err != nil {
-		panic(fmt.Errorf("while writing sitemap: %w", err))
-	}
-
-	if err := renderData.writeHtaccess(output.Path(".htaccess")); err != nil {
-		panic(fmt.Errorf("while writing .htaccess: %w", err))
-	}
-
-	if err := renderData.writeRobotsTxt(output.Path("robots.txt")); err != nil {
-		panic(fmt.Errorf("while writing robots.txt: %w", err))
-	}
-
-	if err := renderData.writeLLMSTxt(output.Path("llms.txt")); err != nil {
-		panic(fmt.Errorf("while writing llms.txt: %w", err))
-	}
-
-	if err := renderData.writeMarkdownList(output.Path("parkruns.md")); err != nil {
-		panic(fmt.Errorf("while writing parkruns.md: %w", err))
-	}
-
-	if *exportCsvFile != "" {
-		if err := parkrun.ExportCsv(events, *exportCsvFile); err != nil {
-			panic(fmt.Errorf("while exporting CSV: %w", err))
+	if err := runCommand(os.Args[1:], os.Stderr); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(os.Stderr, err)
 		}
+		os.Exit(1)
 	}
 }
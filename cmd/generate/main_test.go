@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"encoding/xml"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
 )
 
 type testSitemapURL struct {
@@ -22,9 +26,9 @@ func TestWriteSitemap(t *testing.T) {
 	filePath := filepath.Join(tempDir, "sitemap.xml")
 
 	data := RenderData{
-		CanonicalUrls: []string{
-			"https://example.com/",
-			"https://example.com/articles/a?x=1&y=2",
+		CanonicalUrls: []CanonicalUrl{
+			{Url: "https://example.com/"},
+			{Url: "https://example.com/articles/a?x=1&y=2"},
 		},
 	}
 
@@ -62,3 +66,41 @@ func TestWriteSitemap(t *testing.T) {
 		t.Fatalf("second URL mismatch: %q", sitemap.URLs[1].Loc)
 	}
 }
+
+func TestPlannedArticle(t *testing.T) {
+	content, err := os.ReadFile("../../data/articles/geplante-parkruns/content.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := parkrun.ParsePlannedDate("06.06.2026")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := RenderData{
+		Planned: []*parkrun.PlannedEntry{
+			{PlannedData: parkrun.PlannedData{Name: "Termin parkrun", City: "A", Status: parkrun.PlannedStatusScheduled, Start: start}},
+			{PlannedData: parkrun.PlannedData{Name: "Test parkrun", City: "B", Status: parkrun.PlannedStatusTest}},
+			{PlannedData: parkrun.PlannedData{Name: "Other parkrun", City: "C", Status: parkrun.PlannedStatusRumored}},
+		},
+	}
+
+	tmpl, err := data.TemplateStr(string(content))
+	if err != nil {
+		t.Fatalf("TemplateStr() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, &data); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	html := buf.String()
+	termin := strings.Index(html, "<b>Termin parkrun / A</b>")
+	test := strings.Index(html, "<b>Test parkrun / B</b>")
+	other := strings.Index(html, "<b>Other parkrun / C</b>")
+	if termin < 0 || test < termin || other < test {
+		t.Fatalf("unexpected order of planned parkruns (%d, %d, %d):\n%s", termin, test, other, html)
+	}
+	if !strings.Contains(html, "geplanter Start am 06.06.2026") {
+		t.Fatalf("start date missing:\n%s", html)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// manifestEntry describes a file of the output directory.
+type manifestEntry struct {
+	Hash    string    `json:"hash"`    // hash of the content without the build specific strings
+	Size    int64     `json:"size"`    // size of the file
+	Updated time.Time `json:"updated"` // last change of the content
+}
+
+// buildManifest tracks the files of the output directory across builds: files are only written if their content changed,
+// so unchanged files keep their modification time and their "updated" date (used for the sitemap and for deploying).
+// It compares the rendered output rather than the inputs of a page, so all pages are still rendered on every build.
+type buildManifest struct {
+	Build string                    `json:"build,omitempty"` // ID of the build, ties the results of check, lint and validate to it
+	Files map[string]*manifestEntry `json:"files"`           // by slash separated path relative to the output directory
+
+	outputDir string
+	now       time.Time
+	volatile  [][]byte        // build specific strings (e.g. timestamps), ignored when comparing contents
+	seen      map[string]bool // files produced by the current build
+	written   int
+}
+
+// loadManifest loads the manifest of the previous build (an empty manifest if the file doesn't exist).
+func loadManifest(filePath string, outputDir string, now time.Time, volatile ...string) (*buildManifest, error) {
+	m := &buildManifest{Files: make(map[string]*manifestEntry), outputDir: outputDir, now: now, seen: make(map[string]bool)}
+	for _, v := range volatile {
+		if v != "" {
+			m.volatile = append(m.volatile, []byte(v))
+		}
+	}
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, fmt.Errorf("while parsing %s: %w", filePath, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*manifestEntry)
+	}
+	return m, nil
+}
+
+func (m *buildManifest) relPath(filePath string) (string, error) {
+	rel, err := filepath.Rel(m.outputDir, filePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// contentHash hashes the content without the volatile strings.
+func (m *buildManifest) contentHash(content []byte) string {
+	for _, v := range m.volatile {
+		content = bytes.ReplaceAll(content, v, nil)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// update records the content hash of the file and returns whether the content changed since the previous build.
+// For new files, the content is dated to "updated" (if given).
+func (m *buildManifest) update(rel string, hash string, size int64, updated time.Time) bool {
+	m.seen[rel] = true
+	entry, found := m.Files[rel]
+	if found && entry.Hash == hash {
+		entry.Size = size
+		return false
+	}
+	if found || updated.IsZero() {
+		updated = m.now
+	}
+	m.Files[rel] = &manifestEntry{Hash: hash, Size: size, Updated: updated}
+	return true
+}
+
+// write writes the file, unless the existing file has the same content (ignoring the volatile strings).
+// updated is the date of the content for files that are not in the manifest, yet (zero: now).
+func (m *buildManifest) write(filePath string, content []byte, updated time.Time) error {
+	rel, err := m.relPath(filePath)
+	if err != nil {
+		return err
+	}
+	changed := m.update(rel, m.contentHash(content), int64(len(content)), updated)
+	if !changed && utils.FileExists(filePath) {
+		return nil
+	}
+	m.written += 1
+	return utils.WriteFile(filePath, content)
+}
+
+// record adds a file that has been written by other means (e.g. a copied asset) to the manifest.
+func (m *buildManifest) record(filePath string) error {
+	rel, err := m.relPath(filePath)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	m.update(rel, m.contentHash(content), int64(len(content)), time.Time{})
+	return nil
+}
+
+// hashes returns the content hashes by path.
+func (m *buildManifest) hashes() map[string]string {
+	hashes := make(map[string]string, len(m.Files))
+	for rel, entry := range m.Files {
+		hashes[rel] = entry.Hash
+	}
+	return hashes
+}
+
+// updated returns the date of the last content change of the file (zero for unknown files).
+func (m *buildManifest) updated(filePath string) time.Time {
+	rel, err := m.relPath(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+	if entry, found := m.Files[rel]; found {
+		return entry.Updated
+	}
+	return time.Time{}
+}
+
+// prune removes the files of previous builds that haven't been produced by the current build (e.g. assets with outdated hashes).
+// Only files listed in the manifest are removed, so foreign files in the output directory are never touched.
+func (m *buildManifest) prune() error {
+	stale := make([]string, 0)
+	for rel := range m.Files {
+		if !m.seen[rel] {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		log.Printf("removing stale file %s", rel)
+		if err := os.Remove(filepath.Join(m.outputDir, filepath.FromSlash(rel))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		delete(m.Files, rel)
+	}
+	return nil
+}
+
+// save prunes the stale files and writes the manifest.
+func (m *buildManifest) save(filePath string) error {
+	if err := m.prune(); err != nil {
+		return err
+	}
+	log.Printf("%d files in output directory, %d written", len(m.Files), m.written)
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, buf)
+}
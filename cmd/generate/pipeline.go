@@ -0,0 +1,787 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/assets"
+	"github.com/flopp/parkrun-map/internal/changes"
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// The site is built in four stages:
+//   - load: config, data source, special days, articles and parkrun's events.json
+//   - enrich: planned parkruns, cancellations, results (summary and event wiki pages), order, courses and nearby events
+//   - render: external assets, pages, calendars and articles
+//   - write: changes feed, sitemap, .htaccess, robots.txt, llms.txt and parkruns.md
+//
+// The render and write stages only rewrite output files whose content changed (see buildManifest).
+type site struct {
+	opts     options
+	config   Config
+	now      time.Time
+	data     PathBuilder
+	download PathBuilder
+	output   PathBuilder
+
+	specialDays     *parkrun.SpecialDays
+	articles        []*Article
+	infos           map[string]*parkrun.ParkrunInfo // rows of the data source by event ID
+	events          []*parkrun.Event
+	eventsJson      []parkrun.EventsJsonEntry // the German events of events.json
+	plannedData     []parkrun.PlannedData
+	plannedTimeline []*parkrun.PlannedEntry
+	plannedHistory  *parkrun.PlannedHistory
+	latestDate      time.Time
+
+	manifest *buildManifest
+	assets   *assets.Pipeline
+
+	preview bool // dev server: the changes snapshot and feed are left as they are
+}
+
+// state is the directory of the per-build state (build and asset manifests, changes snapshot and feed, planned
+// history); the dev server keeps its own, but shares the downloads.
+func (s *site) state() PathBuilder {
+	if s.opts.stateDir != "" {
+		return PathBuilder(s.opts.stateDir)
+	}
+	return s.download
+}
+
+func (s *site) maxAge(d time.Duration) time.Time {
+	return s.now.Add(-d)
+}
+
+const (
+	age1h = time.Hour
+	age1d = 24 * time.Hour
+	age1w = 7 * 24 * time.Hour
+)
+
+func randomDuration(min, max time.Duration) time.Duration {
+	delta := max - min
+	return min + time.Duration(rand.Int63n(int64(delta+1)))
+}
+
+// loadSite is the load stage: it reads the config, the data source and the articles and loads the German events from events.json.
+func loadSite(opts options, now time.Time) (*site, error) {
+	config, err := loadConfig(opts.configFile)
+	if err != nil {
+		return nil, err
+	}
+	if opts.disableUmami {
+		config.UmamiWebsiteID = ""
+	}
+
+	s := &site{
+		opts:     opts,
+		config:   config,
+		now:      now,
+		data:     PathBuilder(opts.dataDir),
+		download: PathBuilder(opts.downloadDir),
+		output:   PathBuilder(opts.outputDir),
+	}
+
+	// Saturdays and special days (data/specialdays.json, built-in calendar if missing); the results are expected an hour after the start
+	s.specialDays = parkrun.DefaultSpecialDays()
+	if utils.FileExists(s.data.Path("specialdays.json")) {
+		if s.specialDays, err = parkrun.LoadSpecialDays(s.data.Path("specialdays.json")); err != nil {
+			return nil, fmt.Errorf("while loading special days: %w", err)
+		}
+	}
+
+	if s.articles, err = loadArticles(s.data.Path("articles")); err != nil {
+		return nil, fmt.Errorf("while loading articles: %w", err)
+	}
+
+	// fetch data from the configured data source (Google Sheets or local files)
+	source, err := newDataSource(config)
+	if err != nil {
+		return nil, fmt.Errorf("while creating data source: %w", err)
+	}
+	infos, planned, err := source.Load()
+	if err != nil {
+		return nil, fmt.Errorf("while loading data: %w", err)
+	}
+	s.infos = infos
+	s.plannedData = planned
+
+	// fetch parkrun events
+	events_json_url := "https://images.parkrun.com/events.json"
+	events_json_file := s.download.Path("parkrun", "events.json.gz")
+	if err := utils.DownloadFileIfOlder(events_json_url, events_json_file, s.maxAge(age1d)); err != nil {
+		return nil, fmt.Errorf("while downloading %s to %s: %w", events_json_url, events_json_file, err)
+	}
+
+	// parse parkrun events (only returns German events!)
+	if s.events, err = parkrun.LoadEvents(events_json_file, infos, true /* germanOnly */); err != nil {
+		return nil, fmt.Errorf("loading parkrun events: %w", err)
+	}
+	if s.eventsJson, err = parkrun.LoadEventsJson(events_json_file, true /* germanOnly */); err != nil {
+		return nil, fmt.Errorf("loading parkrun events: %w", err)
+	}
+
+	return s, nil
+}
+
+// loadCancellations downloads the cancellations wiki page (if older than maxAge) and applies the cancellations to the events.
+// It returns the cancellations that could not be matched to any event.
+func loadCancellations(download PathBuilder, events []*parkrun.Event, now time.Time, maxAge time.Time) ([]parkrun.Cancellation, error) {
+	cancellations_wiki_url := "https://wiki.parkrun.com/index.php/Cancellations/Germany"
+	cancellations_file := download.Path("parkrun", "cancellations_wiki")
+	if err := utils.DownloadFileIfOlder(cancellations_wiki_url, cancellations_file, maxAge); err != nil {
+		return nil, fmt.Errorf("while downloading %s to %s: %w", cancellations_wiki_url, cancellations_file, err)
+	}
+	cancellations, err := parkrun.ParseCancellationsWiki(cancellations_file)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing cancellations wiki file %s: %w", cancellations_file, err)
+	}
+	unmatched := parkrun.ApplyCancellations(cancellations, events, now)
+	for _, event := range events {
+		if len(event.Cancellations) > 0 {
+			log.Printf("applied cancellation data from wiki for event %s: %d upcoming, %d past", event.Name, len(event.UpcomingCancellations), len(event.PastCancellations))
+		}
+	}
+	return unmatched, nil
+}
+
+// enrich is the enrich stage: it adds everything to the events that's not part of the data source or events.json.
+func (s *site) enrich() error {
+	if err := s.enrichPlanned(); err != nil {
+		return err
+	}
+
+	// cancellations (before the wiki pages, as cancelled events don't produce new results)
+	unmatchedCancellations, err := loadCancellations(s.download, s.events, s.now, s.maxAge(age1d))
+	if err != nil {
+		return err
+	}
+	for _, c := range unmatchedCancellations {
+		log.Printf("cancellation data from wiki not used for event %s (%s)", c.EventName, c.DateF())
+	}
+
+	if err := s.fetchResults(); err != nil {
+		return err
+	}
+	orderEvents(s.events, s.latestDate)
+
+	if err := s.loadCourses(); err != nil {
+		return err
+	}
+
+	// determine 3 nearby parkruns for each event
+	for _, event := range s.events {
+		event.PopulateNearby(s.events)
+	}
+
+	return nil
+}
+
+// enrichPlanned detects the planned parkruns that appeared in events.json and records how long they were in planning.
+func (s *site) enrichPlanned() error {
+	s.plannedTimeline = parkrun.PlannedTimeline(s.plannedData, s.events)
+	plannedHistoryFile := s.state().Path("planned", "history.json")
+	plannedHistory, err := parkrun.LoadPlannedHistory(plannedHistoryFile)
+	if err != nil {
+		return err
+	}
+	for _, record := range plannedHistory.Update(s.plannedTimeline, s.now) {
+		log.Printf("planned parkrun '%s' appeared in events.json as '%s' after %d days", record.Name, record.EventId, record.DaysInPlanning())
+	}
+	if err := plannedHistory.Save(plannedHistoryFile); err != nil {
+		return fmt.Errorf("while writing %s: %w", plannedHistoryFile, err)
+	}
+	s.plannedHistory = plannedHistory
+	return nil
+}
+
+// fetchResults determines the latest runs of the events from the summary wiki page and the (cached) wiki pages of the events.
+func (s *site) fetchResults() error {
+	// summary wiki file; refreshed hourly once the results of a run day are published
+	summary_wiki_url := "https://wiki.parkrun.com/index.php/Summary_Statistics_By_Event/Germany"
+	summary_file := s.download.Path("parkrun", "summary_wiki")
+	summaryMaxAge := s.maxAge(age1d)
+	if s.specialDays.ResultsPublished(s.now) {
+		summaryMaxAge = s.maxAge(age1h)
+	}
+	if err := utils.DownloadFileIfOlder(summary_wiki_url, summary_file, summaryMaxAge); err != nil {
+		return fmt.Errorf("while downloading %s to %s: %w", summary_wiki_url, summary_file, err)
+	}
+	// the summary only saves downloads; without it, the wiki pages of the events are used
+	summary_data, err := parkrun.ParseSummaryWiki(summary_file)
+	if err != nil {
+		log.Printf("WARNING: while parsing summary wiki file %s: %v", summary_file, err)
+	}
+	for eventName, data := range summary_data {
+		log.Printf("summary data: %s: latest event id=%d date=%v runners=%d volunteers=%d events=%d total runners=%d", eventName, data.LatestEventId, data.LatestDate, data.Runners, data.Volunteers, data.Events, data.TotalRunners)
+	}
+	summaryTime, err := utils.GetMtime(summary_file)
+	if err != nil {
+		return err
+	}
+
+	// cached wiki files
+	schedule := parkrun.WikiSchedule{Now: s.now, SummaryTime: summaryTime, SpecialDays: s.specialDays}
+	cached := make(map[*parkrun.Event]*parkrun.Run)
+	for _, event := range s.events {
+		if !event.Archived() && !event.TemporarilyClosed() {
+			wiki_file := s.download.Path("parkrun", event.Id, "wiki")
+			if utils.FileExists(wiki_file) {
+				if err := event.LoadWiki(wiki_file); err == nil && event.LatestRun != nil {
+					if event.LatestRun.Date.After(schedule.LatestDate) {
+						schedule.LatestDate = event.LatestRun.Date
+					}
+					cached[event] = event.LatestRun
+				} else if err != nil {
+					if err := os.Remove(wiki_file); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	// the summary wiki already contains the latest runs of most events
+	for _, event := range s.events {
+		if event.Archived() || event.TemporarilyClosed() {
+			continue
+		}
+		summary, found := summary_data[event.Name]
+		if !found {
+			if event.Active() {
+				log.Printf("no summary data found for event %s", event.Id)
+			}
+			continue
+		}
+		event.ApplySummary(summary)
+		if event.LatestRun != nil && event.LatestRun.Date.After(schedule.LatestDate) {
+			schedule.LatestDate = event.LatestRun.Date
+		}
+	}
+	log.Printf("lastest existing date: %v", schedule.LatestDate)
+
+	// Pull latest results of the events the schedule considers outdated
+	s.latestDate = schedule.LatestDate
+	for _, event := range s.events {
+		if !event.Active() && !event.Planned() {
+			continue
+		}
+		fetch := schedule.Decide(event, cached[event], event.Summary)
+		log.Printf("%s: fetch=%v maxage=%v (%s)", event.Id, fetch.Fetch, fetch.MaxAge, fetch.Reason)
+		if !fetch.Fetch {
+			continue
+		}
+		wiki_url := event.WikiUrl()
+		wiki_file := s.download.Path("parkrun", event.Id, "wiki")
+		if err := utils.DownloadFileIfOlder(wiki_url, wiki_file, s.maxAge(fetch.MaxAge)); err != nil {
+			if !fetch.Optional {
+				return fmt.Errorf("while downloading '%s' to '%s': %w", wiki_url, wiki_file, err)
+			}
+			// downloading planned events can fail without problems, so we don't force it and just log errors
+			log.Printf("while downloading planned event %s to %s: %v", wiki_url, wiki_file, err)
+			continue
+		}
+		if err := event.LoadWiki(wiki_file); err != nil {
+			log.Printf("while parsing %s: %v", wiki_file, err)
+			continue
+		}
+		// the freshly downloaded wiki page wins over the summary, unless the summary already describes a later run
+		if event.Summary != nil && event.Summary.NewerThan(event.LatestRun) {
+			event.ApplySummary(event.Summary)
+		}
+		if event.LatestRun != nil && event.LatestRun.Date.After(s.latestDate) {
+			s.latestDate = event.LatestRun.Date
+		}
+	}
+
+	return nil
+}
+
+// orderEvents marks the events with results from the latest run day as current and ranks them by their number of runners
+// (events with the same number of runners share a rank).
+func orderEvents(events []*parkrun.Event, latestDate time.Time) {
+	for _, event := range events {
+		event.Current = !event.Archived() && !event.TemporarilyClosed() && event.LatestRun != nil && event.LatestRun.Date.Equal(latestDate)
+	}
+
+	orderedEvents := make([]*parkrun.Event, 0, len(events))
+	for _, event := range events {
+		event.Order = 0
+		if event.Current {
+			orderedEvents = append(orderedEvents, event)
+		}
+	}
+	sort.Slice(orderedEvents, func(i, j int) bool {
+		return orderedEvents[i].LatestRun.RunnerCount > orderedEvents[j].LatestRun.RunnerCount
+	})
+	order := 0
+	orderStep := 1
+	last := 0
+	for _, event := range orderedEvents {
+		if event.LatestRun.RunnerCount != last {
+			order += orderStep
+			last = event.LatestRun.RunnerCount
+			orderStep = 0
+		}
+		orderStep += 1
+		event.Order = order
+	}
+}
+
+// loadCourses loads the course pages of the active events (refreshed weekly) and the course tracks from the Google Maps
+// KML files (refreshed every 100-200 days).
+func (s *site) loadCourses() error {
+	for _, event := range s.events {
+		// the course page (description) is optional: planned and archived events usually have none
+		if event.Active() {
+			course_url := event.CoursePageUrl()
+			course_file := s.download.Path("parkrun", event.Id, "course_page")
+			if err := utils.DownloadFileIfOlder(course_url, course_file, s.maxAge(age1w)); err != nil {
+				log.Printf("while downloading '%s' to '%s': %v", course_url, course_file, err)
+			} else if err := event.LoadCoursePage(course_file); err != nil {
+				log.Printf("while parsing %s: %v", course_file, err)
+			}
+		}
+
+		kml_url := event.GoogleMapsCourseKmlUrl()
+		kml_file := s.download.Path("parkrun", event.Id, event.GoogleMapsCourseId())
+		if err := utils.DownloadFileIfOlder(kml_url, kml_file, s.now.Add(randomDuration(-24*200*time.Hour, -24*100*time.Hour))); err != nil {
+			return fmt.Errorf("while downloading '%s' to '%s': %w", kml_url, kml_file, err)
+		}
+		if err := event.LoadKML(kml_file); err != nil {
+			return fmt.Errorf("file parsing %s: %w", kml_file, err)
+		}
+	}
+	return nil
+}
+
+// downloadAssets fetches the external assets (leaflet, picocss, umami, sortable, jquery, datatables) into the download directory.
+func (s *site) downloadAssets() error {
+	// renovate: datasource=npm depName=leaflet
+	leaflet_version := "1.9.4"
+
+	leaflet_url := PathBuilder(fmt.Sprintf("https://unpkg.com/leaflet@%s", leaflet_version))
+	picocss_url := PathBuilder("https://cdn.jsdelivr.net/npm/@picocss/pico@2/css")
+
+	downloads := [][2]string{
+		// leaflet
+		{leaflet_url.Path("dist/leaflet.js"), s.download.Path("leaflet", "leaflet.js")},
+		{leaflet_url.Path("dist/leaflet.css"), s.download.Path("leaflet", "leaflet.css")},
+		{leaflet_url.Path("dist/images/marker-icon.png"), s.download.Path("leaflet", "marker-icon.png")},
+		{leaflet_url.Path("dist/images/marker-icon-2x.png"), s.download.Path("leaflet", "marker-icon-2x.png")},
+		{leaflet_url.Path("dist/images/marker-shadow.png"), s.download.Path("leaflet", "marker-shadow.png")},
+		// picocss
+		{picocss_url.Path("pico.min.css"), s.download.Path("picocss", "pico.css")},
+	}
+
+	// umami
+	if s.config.UmamiWebsiteID != "" {
+		downloads = append(downloads, [2]string{"https://cloud.umami.is/script.js", s.download.Path("umami", "umami.js")})
+	}
+
+	// sortable
+	sortable_url := "https://cdn.jsdelivr.net/gh/tofsjonas/sortable@latest/dist"
+	downloads = append(downloads,
+		[2]string{sortable_url + "/sortable.min.css", s.download.Path("sortable", "sortable.min.css")},
+		[2]string{sortable_url + "/sortable.min.js", s.download.Path("sortable", "sortable.min.js")},
+	)
+
+	// jquery (needed for datatables)
+	jquery_version := "4.0.0"
+	downloads = append(downloads, [2]string{fmt.Sprintf("https://code.jquery.com/jquery-%s.min.js", jquery_version), s.download.Path("jquery", "jquery.min.js")})
+
+	// datatables
+	datatables_version := "2.3.7"
+	downloads = append(downloads,
+		[2]string{fmt.Sprintf("https://cdn.datatables.net/%s/css/dataTables.dataTables.min.css", datatables_version), s.download.Path("datatables", "dataTables.dataTables.min.css")},
+		[2]string{fmt.Sprintf("https://cdn.datatables.net/%s/js/dataTables.min.js", datatables_version), s.download.Path("datatables", "dataTables.min.js")},
+	)
+
+	for _, d := range downloads {
+		if err := utils.DownloadFileIfOlder(d[0], d[1], s.maxAge(age1w)); err != nil {
+			return fmt.Errorf("while downloading '%s' to '%s': %w", d[0], d[1], err)
+		}
+	}
+
+	// patch datatables js to fix conflict with Pico CSS (datatables buttons are
+	// styled as Pico CSS buttons which breaks the layout)
+	// -> remove '.attr("role","button")' from datatables js
+	content, err := os.ReadFile(s.download.Path("datatables", "dataTables.min.js"))
+	if err != nil {
+		return fmt.Errorf("while reading datatables js file: %w", err)
+	}
+	patchedContent := strings.ReplaceAll(string(content), `.attr("role","button")`, "")
+	if err := os.WriteFile(s.download.Path("datatables", "dataTables.min.js"), []byte(patchedContent), 0644); err != nil {
+		return fmt.Errorf("while writing patched datatables js file: %w", err)
+	}
+
+	return nil
+}
+
+// copyAssets copies the (hashed) assets to the output directory and returns the render data for the pages.
+func (s *site) copyAssets() (*RenderData, error) {
+	outputDir := s.opts.outputDir
+	s.assets = assets.NewPipeline(outputDir, !s.opts.noMinify, s.manifest.record)
+	var addErr error
+	add := func(name, src, dst string, own bool) *assets.Asset {
+		if addErr != nil {
+			return nil
+		}
+		asset, err := s.assets.Add(name, src, dst, own)
+		if err != nil {
+			addErr = fmt.Errorf("while copying %s: %w", src, err)
+		}
+		return asset
+	}
+
+	// render data
+	if err := parkrun.RenderJs(s.events, s.download.Path("data.js")); err != nil {
+		return nil, fmt.Errorf("failed to render data: %w", err)
+	}
+
+	umami_js_file := ""
+	if s.config.UmamiWebsiteID != "" {
+		if umami := add("umami.js", s.download.Path("umami/umami.js"), "umami-HASH.js", false); umami != nil {
+			umami_js_file = umami.File
+		}
+	}
+
+	js_files := []*assets.Asset{
+		add("data.js", s.download.Path("data.js"), "data-HASH.js", true),
+		add("leaflet.js", s.download.Path("leaflet/leaflet.js"), "leaflet-HASH.js", false),
+		add("sortable.js", s.download.Path("sortable/sortable.min.js"), "sortable-HASH.js", false),
+		add("main.js", s.data.Path("static", "main.js"), "main-HASH.js", true),
+	}
+
+	css_files := []*assets.Asset{
+		add("pico.css", s.download.Path("picocss/pico.css"), "pico-HASH.css", false),
+		add("leaflet.css", s.download.Path("leaflet/leaflet.css"), "leaflet-HASH.css", false),
+		add("sortable.css", s.download.Path("sortable/sortable.min.css"), "sortable-HASH.css", false),
+		add("style.css", s.data.Path("static", "style.css"), "style-HASH.css", true),
+	}
+
+	for _, image := range []string{"marker-icon.png", "marker-icon-2x.png", "marker-shadow.png"} {
+		add("images/"+image, s.download.Path("leaflet", image), "images/"+image, false)
+	}
+	for _, color := range []string{"red", "green", "grey"} {
+		for _, image := range []string{fmt.Sprintf("marker-%s-icon.png", color), fmt.Sprintf("marker-%s-icon-2x.png", color)} {
+			add("images/"+image, s.data.Path("static", image), "images/"+image, false)
+		}
+	}
+	add("favicon.ico", s.data.Path("static", "favicon.ico"), "favicon.ico", false)
+	add("favicon.svg", s.data.Path("static", "favicon.svg"), "favicon.svg", false)
+	if addErr != nil {
+		return nil, addErr
+	}
+	if err := s.assets.SaveManifest(s.state().Path("build", "assets.json")); err != nil {
+		return nil, fmt.Errorf("while writing asset manifest: %w", err)
+	}
+	if err := createIndexNow(s.config.IndexNow, outputDir); err != nil {
+		return nil, err
+	}
+	if err := s.manifest.record(filepath.Join(outputDir, fmt.Sprintf("%s.txt", s.config.IndexNow))); err != nil {
+		return nil, err
+	}
+
+	renderData := s.newRenderData()
+	renderData.UmamiJsFile = umami_js_file
+	renderData.JsFiles = js_files
+	renderData.CssFiles = css_files
+	return renderData, nil
+}
+
+func createIndexNow(indexnow string, outputDir string) error {
+	indexNowFile := filepath.Join(outputDir, fmt.Sprintf("%s.txt", indexnow))
+	if _, err := utils.WriteFileIfChanged(indexNowFile, []byte(indexnow)); err != nil {
+		return fmt.Errorf("while writing indexnow file %s: %w", indexNowFile, err)
+	}
+	return nil
+}
+
+// newRenderData creates the render data of the site (without assets).
+func (s *site) newRenderData() *RenderData {
+	active := 0
+	planned := 0
+	archived := 0
+	for _, event := range s.events {
+		if event.Active() {
+			active += 1
+		} else if event.Planned() {
+			planned += 1
+		} else {
+			archived += 1
+		}
+	}
+
+	var plannedHistory []*parkrun.PlannedRecord
+	if s.plannedHistory != nil {
+		plannedHistory = s.plannedHistory.LaunchedRecords()
+	}
+
+	return &RenderData{
+		Config:         &s.config,
+		Events:         s.events,
+		Planned:        s.plannedTimeline,
+		PlannedHistory: plannedHistory,
+		RunDay:         parkrun.NewRunDay(s.specialDays, s.events, s.specialDays.NextRunDay(s.now)),
+		now:            s.now,
+		specialDays:    s.specialDays,
+		Articles:       s.articles,
+		ActiveEvents:   active,
+		PlannedEvents:  planned,
+		ArchivedEvents: archived,
+		Timestamp:      s.timestamp(),
+		NoRewrite:      s.opts.noRewrite,
+		manifest:       s.manifest,
+		assets:         s.assets,
+		minify:         !s.opts.noMinify,
+	}
+}
+
+// timestamp is the build time shown on the pages.
+func (s *site) timestamp() string {
+	return s.now.Format("2006-01-02 15:04:05")
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func (s *site) canonical(path string) string {
+	return fmt.Sprintf("https://%s/%s", s.config.Domain, path)
+}
+
+// manifestFile is the build manifest of the output directory.
+func (s *site) manifestFile() string {
+	return s.state().Path("build", "manifest.json")
+}
+
+// loadManifest loads the build manifest of the previous build and assigns the ID of the current build; the build
+// timestamps change with every build, but don't make a file "changed".
+func (s *site) loadManifest() error {
+	manifest, err := loadManifest(s.manifestFile(), s.opts.outputDir, s.now, s.timestamp(), parkrun.ICalStamp(s.now))
+	if err != nil {
+		return fmt.Errorf("while loading build manifest: %w", err)
+	}
+	manifest.Build = s.now.Format("20060102-150405.000")
+	s.manifest = manifest
+	return nil
+}
+
+// render is the render stage: it downloads and copies the assets and renders all pages, calendars and articles.
+func (s *site) render() (*RenderData, error) {
+	if err := s.downloadAssets(); err != nil {
+		return nil, err
+	}
+	if err := s.loadManifest(); err != nil {
+		return nil, err
+	}
+	renderData, err := s.copyAssets()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.renderPages(renderData); err != nil {
+		return nil, err
+	}
+	return renderData, nil
+}
+
+// rerender reloads the articles and runs the render and write stages again (used by the dev server after changes).
+func (s *site) rerender() error {
+	articles, err := loadArticles(s.data.Path("articles"))
+	if err != nil {
+		return fmt.Errorf("while loading articles: %w", err)
+	}
+	s.articles = articles
+	renderData, err := s.render()
+	if err != nil {
+		return err
+	}
+	return s.write(renderData)
+}
+
+// renderPages renders the templates to the output folder.
+func (s *site) renderPages(renderData *RenderData) error {
+	var latestEventUpdate time.Time
+	var latestArticleUpdate time.Time
+	for _, event := range s.events {
+		u := event.UpdatedAt()
+		if u.After(latestEventUpdate) {
+			latestEventUpdate = u
+		}
+	}
+	for _, article := range s.articles {
+		u := article.UpdatedAt
+		if u.After(latestArticleUpdate) {
+			latestArticleUpdate = u
+		}
+	}
+
+	output := s.output
+	t := PathBuilder(filepath.Join(s.opts.dataDir, "templates"))
+	pages := []struct {
+		file        string
+		template    string
+		title       string
+		description string
+		canonical   string
+		updated     string
+		nav         string
+	}{
+		{"index.html", "index.html", "Karte mit allen parkrun Standorten in Deutschland", "Alle parkrun Standorte in Deutschland auf einer Karte", "", formatDate(latestEventUpdate), "map"},
+		{"liste.html", "liste.html", "Alle parkrun Standorte in Deutschland", "Alle parkrun Standorte in Deutschland.", "liste.html", formatDate(latestEventUpdate), "list"},
+		{"geplant.html", "geplant.html", "Geplante parkruns in Deutschland", "Zeitleiste der geplanten parkrun Standorte in Deutschland: Ankündigung und erwarteter Start", "geplant.html", "", "list"},
+		{"samstag.html", "samstag.html", fmt.Sprintf("parkrun am %s: Welche parkruns finden statt?", renderData.RunDay.DateF()), "Welche parkruns in Deutschland finden am kommenden Samstag statt? Absagen, temporär geschlossene Standorte und Alternativen in der Nähe.", "samstag.html", s.now.Format("2006-01-02"), "saturday"},
+		{"info.html", "info.html", "parkruns Karte - Info", "Informationen", "info.html", "", "info"},
+		{"articles/index.html", "articles.html", "parkrun Artikel", "Informative Artikel rund um parkrun", "articles/", formatDate(latestArticleUpdate), "articles"},
+		{"datenschutz.html", "datenschutz.html", "parkruns Karte - Datenschutz", "Datenschutzinformationen", "datenschutz.html", "", "datenschutz"},
+		{"impressum.html", "impressum.html", "parkruns Karte - Impressum", "Impressum", "impressum.html", "", "impressum"},
+		{"404.html", "404.html", "404 - Seite nicht gefunden", "Die angeforderte Seite wurde nicht gefunden.", "404.html", "", ""},
+	}
+	for _, page := range pages {
+		renderData.set(page.title, page.description, s.canonical(page.canonical), page.updated, page.nav)
+		if err := renderData.render(output.Path(page.file), t.Path(page.template), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
+			return fmt.Errorf("while rendering '%s': %w", page.file, err)
+		}
+	}
+
+	for _, event := range s.events {
+		renderData.Event = event
+		title := fmt.Sprintf("%s, %s", event.FixedName(), event.FixedLocation())
+		description := fmt.Sprintf("Alle Infos zum %s in %s; Strecke, Karte, Statistiken und wichtige Links", event.FixedName(), event.FixedLocation())
+		file := fmt.Sprintf("%s.html", event.Id)
+		// without rewriting, this is the "/ID.html" file, otherwise the extensionless "/ID" (better for SEO)
+		canonicalUrl := renderData.eventCanonical(event.Id)
+		renderData.set(title, description, canonicalUrl, formatDate(event.UpdatedAt()), "list")
+		if err := renderData.render(output.Path(file), t.Path("parkrun.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
+			return fmt.Errorf("while rendering '%s': %w", file, err)
+		}
+
+		icsFile := fmt.Sprintf("%s.ics", event.Id)
+		if err := renderData.writeOutput(output.Path(icsFile), parkrun.ICal(event, s.specialDays, s.config.Domain, canonicalUrl, s.now), time.Time{}); err != nil {
+			return fmt.Errorf("while rendering '%s': %w", icsFile, err)
+		}
+	}
+	renderData.Event = nil
+	eventUrl := func(event *parkrun.Event) string {
+		return renderData.eventCanonical(event.Id)
+	}
+	if err := renderData.writeOutput(output.Path("alle.ics"), parkrun.ICalAll(s.events, s.specialDays, s.config.Domain, eventUrl, s.now), time.Time{}); err != nil {
+		return fmt.Errorf("while rendering 'alle.ics': %w", err)
+	}
+
+	for _, article := range s.articles {
+		contentTemplate, err := renderData.TemplateStr(string(article.Content))
+		if err != nil {
+			return fmt.Errorf("while parsing article content template for '%s': %w", article.Slug, err)
+		}
+		var contentBuffer bytes.Buffer
+		if err := contentTemplate.Execute(&contentBuffer, renderData); err != nil {
+			return fmt.Errorf("while executing article content template for '%s': %w", article.Slug, err)
+		}
+		article.Content = template.HTML(contentBuffer.String())
+
+		renderData.Article = article
+		renderData.set(article.Title+" - parkrun Artikel", article.Summary, s.canonical(fmt.Sprintf("articles/%s.html", article.Slug)), formatDate(article.UpdatedAt), "articles")
+		if err := renderData.render(output.Path("articles", fmt.Sprintf("%s.html", article.Slug)), t.Path("article.html"), t.Path("header.html"), t.Path("footer.html"), t.Path("tail.html")); err != nil {
+			return fmt.Errorf("while rendering article '%s': %w", article.Slug, err)
+		}
+
+		if err := copyArticleAssets(s.data.Path("articles", article.Slug), output.Path("articles", article.Slug), s.manifest.record); err != nil {
+			return fmt.Errorf("while copying assets for article '%s': %w", article.Slug, err)
+		}
+	}
+	renderData.Article = nil
+
+	return nil
+}
+
+// write is the write stage: it updates the changes feed and writes the site-wide files (sitemap, .htaccess, ...).
+func (s *site) write(renderData *RenderData) error {
+	if err := s.writeFeed(renderData); err != nil {
+		return err
+	}
+
+	if err := renderData.writeSitemap(s.output.Path("sitemap.xml")); err != nil {
+		return fmt.Errorf("while writing sitemap: %w", err)
+	}
+
+	if err := renderData.writeHtaccess(s.output.Path(".htaccess")); err != nil {
+		return fmt.Errorf("while writing .htaccess: %w", err)
+	}
+
+	if err := renderData.writeRobotsTxt(s.output.Path("robots.txt")); err != nil {
+		return fmt.Errorf("while writing robots.txt: %w", err)
+	}
+
+	if err := renderData.writeLLMSTxt(s.output.Path("llms.txt")); err != nil {
+		return fmt.Errorf("while writing llms.txt: %w", err)
+	}
+
+	if err := renderData.writeMarkdownList(s.output.Path("parkruns.md")); err != nil {
+		return fmt.Errorf("while writing parkruns.md: %w", err)
+	}
+
+	if err := s.manifest.save(s.manifestFile()); err != nil {
+		return fmt.Errorf("while writing build manifest: %w", err)
+	}
+	return nil
+}
+
+// writeFeed writes feed.xml. The changes against the snapshot of the previous build are added to the feed, and the
+// snapshot of this build is kept for the next build and for "generate diff" (except for previews of the dev server).
+func (s *site) writeFeed(renderData *RenderData) error {
+	feedFile := s.state().Path("changes", "feed.json")
+	feed, err := changes.LoadFeed(feedFile)
+	if err != nil {
+		return err
+	}
+	if !s.preview {
+		articleStates := make([]changes.ArticleState, 0, len(s.articles))
+		for _, article := range s.articles {
+			articleStates = append(articleStates, changes.ArticleState{Slug: article.Slug, Title: article.Title})
+		}
+		snapshot := changes.NewSnapshot(s.events, articleStates)
+		snapshot.EventsJson = s.eventsJson
+		snapshotFile := s.state().Path("changes", "snapshot.json")
+		prevSnapshot, err := changes.LoadSnapshot(snapshotFile)
+		if err != nil {
+			return err
+		}
+		if prevSnapshot != nil {
+			changeList := changes.Diff(*prevSnapshot, snapshot)
+			for _, change := range changeList {
+				log.Printf("change: %s", change.Title)
+			}
+			feed.Add(changeList, s.config.Domain, s.now, func(change changes.Change) string {
+				if change.Kind == changes.KindNewArticle {
+					return s.canonical(fmt.Sprintf("articles/%s.html", change.Id))
+				}
+				return renderData.eventCanonical(change.Id)
+			})
+		}
+		if err := feed.Save(feedFile); err != nil {
+			return fmt.Errorf("while writing %s: %w", feedFile, err)
+		}
+		if err := snapshot.Save(snapshotFile); err != nil {
+			return fmt.Errorf("while writing %s: %w", snapshotFile, err)
+		}
+	}
+	if err := feed.WriteAtom(s.output.Path("feed.xml"), "parkruns.de - Neuigkeiten", "https://"+s.config.Domain, s.now); err != nil {
+		return fmt.Errorf("while writing feed.xml: %w", err)
+	}
+	return s.manifest.record(s.output.Path("feed.xml"))
+}
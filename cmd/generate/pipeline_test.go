@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+func TestOrderEvents(t *testing.T) {
+	latest := time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC)
+	run := func(date time.Time, runners int) *parkrun.Run {
+		return &parkrun.Run{Date: date, RunnerCount: runners}
+	}
+	a := &parkrun.Event{Id: "a", LatestRun: run(latest, 50)}
+	b := &parkrun.Event{Id: "b", LatestRun: run(latest, 80)}
+	c := &parkrun.Event{Id: "c", LatestRun: run(latest, 50)}
+	d := &parkrun.Event{Id: "d", LatestRun: run(latest, 20)}
+	old := &parkrun.Event{Id: "old", LatestRun: run(latest.AddDate(0, 0, -7), 100)}
+	closed := &parkrun.Event{Id: "closed", LatestRun: run(latest, 100), Status: parkrun.StatusTemporarilyClosed}
+	none := &parkrun.Event{Id: "none"}
+
+	orderEvents([]*parkrun.Event{a, b, c, d, old, closed, none}, latest)
+
+	expected := map[*parkrun.Event]int{a: 2, b: 1, c: 2, d: 4, old: 0, closed: 0, none: 0}
+	for event, order := range expected {
+		if event.Order != order {
+			t.Errorf("%s: order = %d, want %d", event.Id, event.Order, order)
+		}
+		if event.Current != (order != 0) {
+			t.Errorf("%s: current = %v, want %v", event.Id, event.Current, order != 0)
+		}
+	}
+}
+
+func TestRenderPages(t *testing.T) {
+	dietenbach := &parkrun.Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Dietenbacher Park", CountryUrl: "www.parkrun.com.de"}
+	dietenbach.LatestRun = &parkrun.Run{Event: dietenbach, Index: 100, Date: time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), RunnerCount: 50}
+	dietenbach.Course = &parkrun.CoursePage{Description: "Zwei Runden um den See.\n\nFlach und asphaltiert.", MapId: "abc"}
+	planned := &parkrun.Event{Id: "neu", Name: "Neu parkrun", Status: parkrun.StatusPlanned}
+
+	output := t.TempDir()
+	s := &site{
+		opts:     options{dataDir: "../../data", outputDir: output},
+		config:   Config{Domain: "example.com"},
+		now:      time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		data:     PathBuilder("../../data"),
+		download: PathBuilder(t.TempDir()),
+		output:   PathBuilder(output),
+		events:   []*parkrun.Event{dietenbach, planned},
+	}
+
+	build := func() {
+		t.Helper()
+		if err := s.loadManifest(); err != nil {
+			t.Fatalf("loadManifest() error = %v", err)
+		}
+		renderData := s.newRenderData()
+		if err := s.renderPages(renderData); err != nil {
+			t.Fatalf("renderPages() error = %v", err)
+		}
+		if err := s.write(renderData); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	build()
+
+	for _, file := range []string{"index.html", "liste.html", "samstag.html", "404.html", "articles/index.html", "dietenbach.html", "dietenbach.ics", "alle.ics", "sitemap.xml", ".htaccess", "parkruns.md", "feed.xml"} {
+		if _, err := os.Stat(filepath.Join(output, file)); err != nil {
+			t.Errorf("missing output file %s: %v", file, err)
+		}
+	}
+	page, err := os.ReadFile(filepath.Join(output, "dietenbach.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "Zwei Runden um den See.<br><br>Flach und asphaltiert.") {
+		t.Errorf("course description missing in dietenbach.html")
+	}
+	sitemap, err := os.ReadFile(filepath.Join(output, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sitemap), "https://example.com/dietenbach") || strings.Contains(string(sitemap), "404.html") {
+		t.Errorf("unexpected sitemap:\n%s", sitemap)
+	}
+
+	// a second build (new timestamp, changed event) only rewrites the changed files and removes stale files
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, file := range []string{"info.html", "dietenbach.html"} {
+		if err := os.Chtimes(filepath.Join(output, file), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(output, "stale.html"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.manifest.record(filepath.Join(output, "stale.html")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.manifest.save(s.manifestFile()); err != nil {
+		t.Fatal(err)
+	}
+	s.now = s.now.Add(24 * time.Hour)
+	dietenbach.LatestRun.RunnerCount = 60
+	build()
+
+	for file, wantUnchanged := range map[string]bool{"info.html": true, "dietenbach.html": false} {
+		info, err := os.Stat(filepath.Join(output, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unchanged := info.ModTime().Equal(old); unchanged != wantUnchanged {
+			t.Errorf("%s: unchanged = %v, want %v", file, unchanged, wantUnchanged)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "stale.html")); err == nil {
+		t.Errorf("stale.html has not been removed")
+	}
+	sitemap, err = os.ReadFile(filepath.Join(output, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<loc>https://example.com/info.html</loc>\n    <lastmod>2026-06-03</lastmod>", "<loc>https://example.com/dietenbach</loc>\n    <lastmod>2026-06-04</lastmod>"} {
+		if !strings.Contains(string(sitemap), want) {
+			t.Errorf("sitemap doesn't contain %q:\n%s", want, sitemap)
+		}
+	}
+}
+
+func TestWritePreview(t *testing.T) {
+	dietenbach := &parkrun.Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Dietenbacher Park", CountryUrl: "www.parkrun.com.de"}
+	dietenbach.LatestRun = &parkrun.Run{Event: dietenbach, Index: 100, Date: time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), RunnerCount: 50}
+
+	output := t.TempDir()
+	download := PathBuilder(t.TempDir())
+	state := PathBuilder(t.TempDir())
+	s := &site{
+		opts:     options{dataDir: "../../data", outputDir: output, stateDir: string(state)},
+		config:   Config{Domain: "example.com"},
+		now:      time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		data:     PathBuilder("../../data"),
+		download: download,
+		output:   PathBuilder(output),
+		events:   []*parkrun.Event{dietenbach},
+		preview:  true,
+	}
+	if err := s.loadManifest(); err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	renderData := s.newRenderData()
+	if err := s.renderPages(renderData); err != nil {
+		t.Fatalf("renderPages() error = %v", err)
+	}
+	if err := s.write(renderData); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	// the dev server serves a feed, but doesn't update the changes snapshot and feed of the builds
+	if _, err := os.Stat(filepath.Join(output, "feed.xml")); err != nil {
+		t.Errorf("missing output file feed.xml: %v", err)
+	}
+	for _, file := range []string{"snapshot.json", "feed.json"} {
+		if _, err := os.Stat(state.Path("changes", file)); err == nil {
+			t.Errorf("preview wrote changes/%s", file)
+		}
+	}
+
+	// the build manifest is kept in the state directory, not next to the downloads of the build
+	if _, err := os.Stat(state.Path("build", "manifest.json")); err != nil {
+		t.Errorf("missing build manifest in the state directory: %v", err)
+	}
+	if _, err := os.Stat(download.Path("build", "manifest.json")); err == nil {
+		t.Errorf("preview wrote the build manifest of the build")
+	}
+}
+
+func TestRenderCalendarsNoRewrite(t *testing.T) {
+	dietenbach := &parkrun.Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Dietenbacher Park", CountryUrl: "www.parkrun.com.de"}
+	dietenbach.LatestRun = &parkrun.Run{Event: dietenbach, Index: 100, Date: time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), RunnerCount: 50}
+
+	output := t.TempDir()
+	s := &site{
+		opts:     options{dataDir: "../../data", outputDir: output, noRewrite: true},
+		config:   Config{Domain: "example.com"},
+		now:      time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		data:     PathBuilder("../../data"),
+		download: PathBuilder(t.TempDir()),
+		output:   PathBuilder(output),
+		events:   []*parkrun.Event{dietenbach},
+	}
+	if err := s.loadManifest(); err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if err := s.renderPages(s.newRenderData()); err != nil {
+		t.Fatalf("renderPages() error = %v", err)
+	}
+
+	// the calendars link to the canonical URL of the event page, which has an extension without rewriting
+	for _, file := range []string{"dietenbach.ics", "alle.ics"} {
+		content, err := os.ReadFile(filepath.Join(output, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "URL:https://example.com/dietenbach.html\r\n") {
+			t.Errorf("%s doesn't link to dietenbach.html:\n%s", file, content)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rewriteCond is a "RewriteCond" of .htaccess; only %{HTTP_HOST} and %{THE_REQUEST} are supported.
+type rewriteCond struct {
+	variable string
+	pattern  *regexp.Regexp
+}
+
+// rewriteRule is a "RewriteRule" of .htaccess with its preceding conditions.
+type rewriteRule struct {
+	conds    []rewriteCond
+	pattern  *regexp.Regexp
+	target   string
+	redirect int // status code of [R=...], 0 for internal rewrites
+	last     bool
+}
+
+// htaccess is the subset of Apache's .htaccess directives written by writeHtaccess, emulated by the dev server.
+type htaccess struct {
+	rules          []rewriteRule
+	errorDocuments map[int]string
+	types          map[string]string
+}
+
+var reRewriteVariable = regexp.MustCompile(`^%\{([A-Z_]+)\}$`)
+
+func compileRewritePattern(pattern string, flags string) (*regexp.Regexp, error) {
+	if strings.Contains(flags, "NC") {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// parseHtaccess reads the rewrite rules, error documents and content types of an .htaccess file.
+func parseHtaccess(content []byte) (*htaccess, error) {
+	h := &htaccess{errorDocuments: make(map[int]string), types: make(map[string]string)}
+	var conds []rewriteCond
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		flags := ""
+		if last := fields[len(fields)-1]; len(fields) > 3 && strings.HasPrefix(last, "[") {
+			flags = strings.Trim(last, "[]")
+			fields = fields[:len(fields)-1]
+		}
+		switch fields[0] {
+		case "ErrorDocument":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: malformed ErrorDocument", lineNumber)
+			}
+			status, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad status code '%s'", lineNumber, fields[1])
+			}
+			h.errorDocuments[status] = fields[2]
+		case "AddType":
+			for _, ext := range fields[2:] {
+				h.types[ext] = fields[1]
+			}
+		case "RewriteCond":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: malformed RewriteCond", lineNumber)
+			}
+			m := reRewriteVariable.FindStringSubmatch(fields[1])
+			if m == nil {
+				return nil, fmt.Errorf("line %d: unsupported RewriteCond variable '%s'", lineNumber, fields[1])
+			}
+			pattern, err := compileRewritePattern(fields[2], flags)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			conds = append(conds, rewriteCond{m[1], pattern})
+		case "RewriteRule":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: malformed RewriteRule", lineNumber)
+			}
+			pattern, err := compileRewritePattern(fields[1], flags)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			rule := rewriteRule{conds: conds, pattern: pattern, target: fields[2]}
+			for _, flag := range strings.Split(flags, ",") {
+				switch {
+				case flag == "L":
+					rule.last = true
+				case flag == "R":
+					rule.redirect = http.StatusFound
+				case strings.HasPrefix(flag, "R="):
+					if rule.redirect, err = strconv.Atoi(strings.TrimPrefix(flag, "R=")); err != nil {
+						return nil, fmt.Errorf("line %d: bad redirect flag '%s'", lineNumber, flag)
+					}
+				}
+			}
+			h.rules = append(h.rules, rule)
+			conds = nil
+		}
+	}
+	return h, scanner.Err()
+}
+
+func expandRewrite(target string, ruleMatch []string, condMatch []string) string {
+	expand := func(s string, prefix byte, match []string) string {
+		for i := len(match) - 1; i >= 0; i-- {
+			s = strings.ReplaceAll(s, fmt.Sprintf("%c%d", prefix, i), match[i])
+		}
+		return s
+	}
+	return expand(expand(target, '$', ruleMatch), '%', condMatch)
+}
+
+// rewrite applies the rewrite rules to the request; it returns the rewritten path (without leading slash),
+// or the location and status code of a redirect.
+func (h *htaccess) rewrite(r *http.Request) (string, string, int) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	variables := map[string]string{
+		"HTTP_HOST":   r.Host,
+		"THE_REQUEST": fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), r.Proto),
+	}
+	for _, rule := range h.rules {
+		ruleMatch := rule.pattern.FindStringSubmatch(p)
+		if ruleMatch == nil {
+			continue
+		}
+		var condMatch []string
+		matches := true
+		for _, cond := range rule.conds {
+			if condMatch = cond.pattern.FindStringSubmatch(variables[cond.variable]); condMatch == nil {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		target := expandRewrite(rule.target, ruleMatch, condMatch)
+		if rule.redirect != 0 {
+			if !strings.Contains(target, "://") {
+				target = "/" + target // RewriteBase /
+			}
+			return "", target, rule.redirect
+		}
+		p = target
+		if rule.last {
+			break
+		}
+	}
+	return p, "", 0
+}
+
+// liveReloadScript is injected into all HTML pages served by the dev server; it reloads the page after a re-render.
+const liveReloadScript = `<script>new EventSource("/__livereload").onmessage = function() { location.reload(); };</script>`
+
+// devServer serves the output directory like the production web server (rewrites and error documents of .htaccess)
+// and notifies the open pages after re-renders.
+type devServer struct {
+	outputDir string
+
+	mu       sync.Mutex
+	htaccess *htaccess
+	clients  map[chan struct{}]struct{}
+}
+
+func newDevServer(outputDir string) *devServer {
+	server := &devServer{outputDir: outputDir, clients: make(map[chan struct{}]struct{})}
+	server.reloadHtaccess()
+	return server
+}
+
+// reloadHtaccess reads the rules of the (re-)generated .htaccess file; without the file, no rules are applied.
+func (server *devServer) reloadHtaccess() {
+	h := &htaccess{errorDocuments: make(map[int]string), types: make(map[string]string)}
+	if content, err := os.ReadFile(filepath.Join(server.outputDir, ".htaccess")); err == nil {
+		if parsed, err := parseHtaccess(content); err != nil {
+			log.Printf("while parsing .htaccess: %v", err)
+		} else {
+			h = parsed
+		}
+	}
+	server.mu.Lock()
+	server.htaccess = h
+	server.mu.Unlock()
+}
+
+// notify tells all open pages to reload.
+func (server *devServer) notify() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for client := range server.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (server *devServer) serveLiveReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	client := make(chan struct{}, 1)
+	server.mu.Lock()
+	server.clients[client] = struct{}{}
+	server.mu.Unlock()
+	defer func() {
+		server.mu.Lock()
+		delete(server.clients, client)
+		server.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			fmt.Fprintf(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (server *devServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/__livereload" {
+		server.serveLiveReload(w, r)
+		return
+	}
+
+	server.mu.Lock()
+	h := server.htaccess
+	server.mu.Unlock()
+
+	p, location, status := h.rewrite(r)
+	if status != 0 {
+		http.Redirect(w, r, location, status)
+		return
+	}
+
+	p = path.Clean("/" + p)
+	file := filepath.Join(server.outputDir, filepath.FromSlash(p))
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		file = filepath.Join(file, "index.html")
+	}
+	if server.serveFile(w, file, http.StatusOK, h) {
+		return
+	}
+
+	if errorDocument, ok := h.errorDocuments[http.StatusNotFound]; ok {
+		if server.serveFile(w, filepath.Join(server.outputDir, filepath.FromSlash(path.Clean("/"+errorDocument))), http.StatusNotFound, h) {
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// serveFile writes the file with the given status code (injecting the live reload script into HTML files);
+// it returns false if the file doesn't exist.
+func (server *devServer) serveFile(w http.ResponseWriter, file string, status int, h *htaccess) bool {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	ext := filepath.Ext(file)
+	contentType := h.types[ext]
+	if contentType == "" {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	if strings.HasPrefix(contentType, "text/html") {
+		if i := bytes.LastIndex(content, []byte("</body>")); i >= 0 {
+			content = append(content[:i:i], append([]byte(liveReloadScript), content[i:]...)...)
+		} else {
+			content = append(content, []byte(liveReloadScript)...)
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(content)
+	return true
+}
+
+// dirState maps the files of the watched directories to their modification time and size.
+type dirState map[string]string
+
+// scanDirs returns the state of all files within the directories (missing directories are ignored).
+func scanDirs(dirs []string) (dirState, error) {
+	state := make(dirState)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			state[p] = fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// changedFiles returns the files that have been added, modified or removed between the states.
+func changedFiles(before, after dirState) []string {
+	changed := make([]string, 0)
+	for file, s := range after {
+		if before[file] != s {
+			changed = append(changed, file)
+		}
+	}
+	for file := range before {
+		if _, found := after[file]; !found {
+			changed = append(changed, file)
+		}
+	}
+	return changed
+}
+
+// watchDirs polls the directories and calls onChange with the changed files (polling avoids platform specific file notifications).
+func watchDirs(dirs []string, interval time.Duration, onChange func(changed []string)) error {
+	state, err := scanDirs(dirs)
+	if err != nil {
+		return err
+	}
+	for {
+		time.Sleep(interval)
+		newState, err := scanDirs(dirs)
+		if err != nil {
+			log.Printf("while watching %v: %v", dirs, err)
+			continue
+		}
+		if changed := changedFiles(state, newState); len(changed) > 0 {
+			onChange(changed)
+		}
+		state = newState
+	}
+}
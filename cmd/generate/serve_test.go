@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+func TestDevServer(t *testing.T) {
+	outputDir := t.TempDir()
+	files := map[string]string{
+		"index.html":          "<html><body>index</body></html>",
+		"dietenbach.html":     "<html><body>dietenbach</body></html>",
+		"404.html":            "<html><body>not found</body></html>",
+		"dietenbach.ics":      "BEGIN:VCALENDAR",
+		"articles/index.html": "<html><body>articles</body></html>",
+	}
+	for file, content := range files {
+		path := filepath.Join(outputDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := RenderData{Events: []*parkrun.Event{{Id: "dietenbach"}}}
+	if err := data.writeHtaccess(filepath.Join(outputDir, ".htaccess")); err != nil {
+		t.Fatalf("writeHtaccess() error = %v", err)
+	}
+
+	server := newDevServer(outputDir)
+	testCases := []struct {
+		name        string
+		host        string
+		path        string
+		status      int
+		location    string
+		contentType string
+		contains    string
+	}{
+		{name: "index", path: "/", status: http.StatusOK, contentType: "text/html", contains: "index"},
+		{name: "extensionless", path: "/dietenbach", status: http.StatusOK, contentType: "text/html", contains: "dietenbach"},
+		{name: "trailing slash", path: "/dietenbach/", status: http.StatusOK, contains: "dietenbach"},
+		{name: "html redirect", path: "/dietenbach.html", status: http.StatusMovedPermanently, location: "/dietenbach"},
+		{name: "www redirect", host: "www.parkrun.example", path: "/dietenbach", status: http.StatusMovedPermanently, location: "https://parkrun.example/dietenbach"},
+		{name: "calendar", path: "/dietenbach.ics", status: http.StatusOK, contentType: "text/calendar", contains: "BEGIN:VCALENDAR"},
+		{name: "directory redirect", path: "/articles", status: http.StatusMovedPermanently, location: "/articles/"},
+		{name: "directory", path: "/articles/", status: http.StatusOK, contains: "articles"},
+		{name: "not found", path: "/unknown", status: http.StatusNotFound, contentType: "text/html", contains: "not found"},
+		{name: "live reload script", path: "/", status: http.StatusOK, contains: liveReloadScript + "</body>"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+
+			if res.StatusCode != tc.status {
+				t.Fatalf("GET %s: status = %d, want %d", tc.path, res.StatusCode, tc.status)
+			}
+			if location := res.Header.Get("Location"); location != tc.location {
+				t.Fatalf("GET %s: location = %q, want %q", tc.path, location, tc.location)
+			}
+			if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, tc.contentType) {
+				t.Fatalf("GET %s: content type = %q, want %q", tc.path, contentType, tc.contentType)
+			}
+			if !strings.Contains(string(body), tc.contains) {
+				t.Fatalf("GET %s: body doesn't contain %q:\n%s", tc.path, tc.contains, string(body))
+			}
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.html")
+	if err := os.WriteFile(file, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := scanDirs([]string{dir, filepath.Join(dir, "missing")})
+	if err != nil {
+		t.Fatalf("scanDirs() error = %v", err)
+	}
+
+	if err := os.WriteFile(file, []byte("ab"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(dir, "sub", "b.css")
+	if err := os.MkdirAll(filepath.Dir(added), 0770); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(added, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	after, err := scanDirs([]string{dir})
+	if err != nil {
+		t.Fatalf("scanDirs() error = %v", err)
+	}
+
+	changed := changedFiles(before, after)
+	if len(changed) != 2 {
+		t.Fatalf("changedFiles() = %v, want %s and %s", changed, file, added)
+	}
+	if removed := changedFiles(after, dirState{}); len(removed) != 2 {
+		t.Fatalf("changedFiles() = %v, want 2 removed files", removed)
+	}
+	if unchanged := changedFiles(after, after); len(unchanged) != 0 {
+		t.Fatalf("changedFiles() = %v, want no changes", unchanged)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/flopp/parkrun-map/internal/assets"
+	"github.com/flopp/parkrun-map/internal/report"
+)
+
+// linkAttributes are the attributes with URLs by tag name
+var linkAttributes = map[string]string{
+	"a":      "href",
+	"link":   "href",
+	"script": "src",
+	"img":    "src",
+	"iframe": "src",
+	"source": "src",
+}
+
+// pageLinks are the URLs referenced by a generated HTML page.
+type pageLinks struct {
+	links     []string
+	canonical string
+}
+
+func parsePageLinks(content []byte) (pageLinks, error) {
+	var page pageLinks
+	tokenizer := html.NewTokenizer(strings.NewReader(string(content)))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return page, nil
+			}
+			return page, tokenizer.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			attribute, found := linkAttributes[token.Data]
+			if !found {
+				continue
+			}
+			rel := ""
+			value := ""
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case attribute:
+					value = attr.Val
+				case "rel":
+					rel = attr.Val
+				}
+			}
+			if value == "" {
+				continue
+			}
+			if token.Data == "link" && rel == "canonical" {
+				page.canonical = value
+			}
+			page.links = append(page.links, value)
+		}
+	}
+}
+
+// siteValidator checks the generated output directory.
+type siteValidator struct {
+	outputDir string
+	domain    string
+	htaccess  *htaccess
+}
+
+// internalPath returns the path of an internal link (relative to the page's path), or false for external links.
+func (v *siteValidator) internalPath(pagePath string, link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if (u.Scheme == "https" || u.Scheme == "http" || u.Scheme == "") && u.Host == v.domain {
+			return u.Path, u.Path != ""
+		}
+		return "", false
+	}
+	if u.Path == "" {
+		// "#anchor" or "?query"
+		return "", false
+	}
+	base := &url.URL{Path: pagePath}
+	return base.ResolveReference(u).Path, true
+}
+
+// resolve maps the URL path to a file of the output directory like the web server (rewrites, redirects, index files).
+func (v *siteValidator) resolve(urlPath string) (string, bool) {
+	for redirects := 0; redirects < 5; redirects++ {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		if err != nil {
+			return "", false
+		}
+		req.Host = v.domain
+		p, location, status := v.htaccess.rewrite(req)
+		if status != 0 {
+			target, err := url.Parse(location)
+			if err != nil || (target.Host != "" && target.Host != v.domain) {
+				return "", false
+			}
+			urlPath = target.Path
+			continue
+		}
+		file := filepath.Join(v.outputDir, filepath.FromSlash(path.Clean("/"+p)))
+		if info, err := os.Stat(file); err == nil {
+			if info.IsDir() {
+				file = filepath.Join(file, "index.html")
+				if _, err := os.Stat(file); err != nil {
+					return "", false
+				}
+			}
+			return file, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// listPages returns a short list of pages for the evidence of a finding.
+func listPages(pages []string) string {
+	sort.Strings(pages)
+	if len(pages) > 3 {
+		return fmt.Sprintf("%s (+%d more)", strings.Join(pages[:3], ", "), len(pages)-3)
+	}
+	return strings.Join(pages, ", ")
+}
+
+// validate checks the generated output: internal links and assets resolve to files, every event is listed in the
+// sitemap and in parkruns.md, the hashed assets exist and the canonical URLs are unique.
+func (s *site) validate(renderData *RenderData) (*report.Report, error) {
+	r := report.New()
+	outputDir := s.opts.outputDir
+
+	h := &htaccess{errorDocuments: make(map[int]string), types: make(map[string]string)}
+	if content, err := os.ReadFile(s.output.Path(".htaccess")); err == nil {
+		if h, err = parseHtaccess(content); err != nil {
+			return nil, fmt.Errorf("while parsing .htaccess: %w", err)
+		}
+	}
+	v := &siteValidator{outputDir: outputDir, domain: s.config.Domain, htaccess: h}
+
+	assetFiles := make([]string, 0)
+	for _, asset := range append(append([]*assets.Asset{}, renderData.JsFiles...), renderData.CssFiles...) {
+		assetFiles = append(assetFiles, asset.File)
+	}
+	if renderData.UmamiJsFile != "" {
+		assetFiles = append(assetFiles, renderData.UmamiJsFile)
+	}
+	// missing assets are reported by the asset check, not for every page
+	assetPaths := make(map[string]bool)
+	for _, asset := range assetFiles {
+		assetPaths["/"+asset] = true
+	}
+
+	// internal links of all pages
+	brokenLinks := make(map[string][]string)
+	canonicals := make(map[string][]string)
+	err := filepath.WalkDir(outputDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(filePath) != ".html" {
+			return nil
+		}
+		rel, err := filepath.Rel(outputDir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		page, err := parsePageLinks(content)
+		if err != nil {
+			r.Error("html", rel, "", "parsing page: %v", err)
+			return nil
+		}
+		r.Checked("internal-links", rel)
+		for _, link := range page.links {
+			p, internal := v.internalPath("/"+rel, link)
+			if !internal || assetPaths[p] {
+				continue
+			}
+			if _, found := v.resolve(p); !found {
+				brokenLinks[p] = append(brokenLinks[p], rel)
+			}
+		}
+		if page.canonical != "" {
+			canonicals[page.canonical] = append(canonicals[page.canonical], rel)
+		} else if rel != "404.html" {
+			r.Warning("canonical", rel, "", "page has no canonical URL")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for p, pages := range brokenLinks {
+		r.Error("internal-links", "", listPages(pages), "link target %s does not exist", p)
+	}
+
+	r.Checked("canonical", "")
+	for canonical, pages := range canonicals {
+		if len(pages) > 1 {
+			r.Error("canonical", "", listPages(pages), "canonical URL %s is used by %d pages", canonical, len(pages))
+		}
+	}
+
+	// hashed assets
+	r.Checked("assets", "")
+	for _, asset := range assetFiles {
+		if _, err := os.Stat(s.output.Path(filepath.FromSlash(asset))); err != nil {
+			r.Error("assets", "", asset, "asset file does not exist")
+		}
+	}
+
+	// sitemap and markdown list
+	sitemapUrls := make(map[string]bool)
+	if content, err := os.ReadFile(s.output.Path("sitemap.xml")); err != nil {
+		r.Error("sitemap", "", "sitemap.xml", "reading sitemap: %v", err)
+	} else {
+		var sitemap struct {
+			URLs []struct {
+				Loc string `xml:"loc"`
+			} `xml:"url"`
+		}
+		if err := xml.Unmarshal(content, &sitemap); err != nil {
+			r.Error("sitemap", "", "sitemap.xml", "parsing sitemap: %v", err)
+		}
+		for _, u := range sitemap.URLs {
+			sitemapUrls[u.Loc] = true
+		}
+	}
+	markdown, err := os.ReadFile(s.output.Path("parkruns.md"))
+	if err != nil {
+		r.Error("markdown-list", "", "parkruns.md", "reading markdown list: %v", err)
+	}
+	for _, event := range s.events {
+		r.Checked("sitemap", event.Id)
+		r.Checked("markdown-list", event.Id)
+		if canonical := renderData.eventCanonical(event.Id); !sitemapUrls[canonical] {
+			r.Error("sitemap", event.Id, canonical, "event page is missing in sitemap.xml")
+		}
+		if markdown != nil && !strings.Contains(string(markdown), fmt.Sprintf("/%s)", event.Id)) {
+			r.Error("markdown-list", event.Id, "parkruns.md", "event is missing in parkruns.md")
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/assets"
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+func TestValidate(t *testing.T) {
+	dietenbach := &parkrun.Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Dietenbacher Park", CountryUrl: "www.parkrun.com.de"}
+	dietenbach.LatestRun = &parkrun.Run{Event: dietenbach, Index: 100, Date: time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), RunnerCount: 50}
+	output := t.TempDir()
+	s := &site{
+		opts:     options{dataDir: "../../data", outputDir: output},
+		config:   Config{Domain: "example.com"},
+		now:      time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		data:     PathBuilder("../../data"),
+		download: PathBuilder(t.TempDir()),
+		output:   PathBuilder(output),
+		events:   []*parkrun.Event{dietenbach},
+	}
+	if err := s.loadManifest(); err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	renderData := s.newRenderData()
+	renderData.JsFiles = []*assets.Asset{{Name: "main.js", File: "main-1234.js"}}
+	renderData.CssFiles = []*assets.Asset{{Name: "style.css", File: "style-1234.css"}}
+	for _, file := range []string{"main-1234.js", "style-1234.css", "favicon.svg"} {
+		if err := os.WriteFile(filepath.Join(output, file), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.renderPages(renderData); err != nil {
+		t.Fatalf("renderPages() error = %v", err)
+	}
+	if err := s.write(renderData); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	r, err := s.validate(renderData)
+	if err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if r.HasErrors() {
+		t.Fatalf("unexpected findings: %v", r.Findings)
+	}
+
+	// break the output: missing asset, missing link target, duplicate canonical URL, event missing in sitemap and list
+	if err := os.Remove(filepath.Join(output, "style-1234.css")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(output, "geplant.html")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.ReadFile(filepath.Join(output, "info.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(output, "info2.html"), info, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.events = append(s.events, &parkrun.Event{Id: "hasenheide"})
+
+	r, err = s.validate(renderData)
+	if err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	got := make([]string, 0)
+	for _, f := range r.Findings {
+		got = append(got, f.Check+" "+f.Event+" "+f.Message)
+	}
+	sort.Strings(got)
+	want := []string{
+		"assets  asset file does not exist",
+		"canonical  canonical URL https://example.com/info.html is used by 2 pages",
+		"internal-links  link target /geplant.html does not exist",
+		"markdown-list hasenheide event is missing in parkruns.md",
+		"sitemap hasenheide event page is missing in sitemap.xml",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("findings =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInternalPath(t *testing.T) {
+	v := &siteValidator{domain: "example.com"}
+	testCases := []struct {
+		link         string
+		wantPath     string
+		wantInternal bool
+	}{
+		{"/liste.html", "/liste.html", true},
+		{"/dietenbach?x=1#karte", "/dietenbach", true},
+		{"after-parkrun-cafe.html", "/articles/after-parkrun-cafe.html", true},
+		{"../index.html", "/index.html", true},
+		{"https://example.com/dietenbach", "/dietenbach", true},
+		{"https://www.parkrun.com.de/dietenbach/", "", false},
+		{"//cdn.example.org/x.js", "", false},
+		{"mailto:mail@example.com", "", false},
+		{"#top", "", false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.link, func(t *testing.T) {
+			p, internal := v.internalPath("/articles/index.html", tc.link)
+			if p != tc.wantPath || internal != tc.wantInternal {
+				t.Fatalf("internalPath(%q) = %q, %v, want %q, %v", tc.link, p, internal, tc.wantPath, tc.wantInternal)
+			}
+		})
+	}
+}
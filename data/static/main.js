@@ -6,6 +6,16 @@ var on_load = function(f) {
     }
 }
 
+// escapeHtml escapes a data value for use in HTML content and attribute values (e.g. of popups).
+const escapeHtml = function(s) {
+    return String(s)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+};
+
 const trackPanes = {
     archived: 'parkrun-tracks-archived-pane',
     planned: 'parkrun-tracks-planned-pane',
@@ -159,11 +169,19 @@ const loadMap = function (id, hash) {
                 circle.addTo(map);
             }
 
-            const marker = L.marker([parkrun.lat, parkrun.lon], {icon: blueIcon, zIndexOffset: 2000});
-            //const marker = L.circleMarker([parkrun.lat, parkrun.lon], {color: "darkblue", fillColor: "blue", fillOpacity: 1, radius: 8});
-            marker
-                .addTo(map)
-                .bindPopup(`<a href="${parkrun.id}.html"><b>${parkrun.name}</b></a><br>${parkrun.location}`);
+            if (parkrun.cancellation) {
+                // upcoming cancellation => highlight with a red marker above all others
+                const marker = L.marker([parkrun.lat, parkrun.lon], {icon: redIcon, zIndexOffset: 3000});
+                marker
+                    .addTo(map)
+                    .bindPopup(`<a href="${parkrun.id}.html"><b>${parkrun.name}</b></a><br>${parkrun.location}<br><span class="tag-red">⚠️ Absage am ${escapeHtml(parkrun.cancellation.date)}</span><br>${escapeHtml(parkrun.cancellation.reason)}`);
+            } else {
+                const marker = L.marker([parkrun.lat, parkrun.lon], {icon: blueIcon, zIndexOffset: 2000});
+                //const marker = L.circleMarker([parkrun.lat, parkrun.lon], {color: "darkblue", fillColor: "blue", fillOpacity: 1, radius: 8});
+                marker
+                    .addTo(map)
+                    .bindPopup(`<a href="${parkrun.id}.html"><b>${parkrun.name}</b></a><br>${parkrun.location}`);
+            }
         } else if (parkrun.planned) {
             const marker = L.marker([parkrun.lat, parkrun.lon], {icon: greenIcon, zIndexOffset: 1000});
             marker
@@ -227,6 +245,15 @@ const loadParkrunMap = function (divId) {
             marker.addTo(map);    
         }
 
+        if (parkrun.cafe && parkrun.cafe.lat !== undefined) {
+            const cafeLatLng = L.latLng(parkrun.cafe.lat, parkrun.cafe.lon);
+            const cafeMarker = L.circleMarker(cafeLatLng, {color: "saddlebrown", fillColor: "peru", fillOpacity: 1, radius: 7});
+            cafeMarker
+                .addTo(map)
+                .bindPopup(`<b>Café nach dem Lauf</b><br><a target="_blank" href="${escapeHtml(parkrun.cafe.url)}">${escapeHtml(parkrun.cafe.name)}</a>`);
+            bounds.extend(cafeLatLng);
+        }
+
         parkrun.tracks.forEach(latlngs => {
             bounds.extend(L.latLngBounds(latlngs));
             L.polyline(latlngs, {color: 'red'}).addTo(map);
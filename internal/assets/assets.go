@@ -0,0 +1,107 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// Asset is a file of the output directory with a logical name.
+type Asset struct {
+	Name      string `json:"name"`      // logical name, e.g. "main.js"
+	File      string `json:"file"`      // path relative to the output directory, e.g. "main-0123456789abcdef.js"
+	Integrity string `json:"integrity"` // subresource integrity, e.g. "sha384-..."
+}
+
+// Path returns the URL path of the asset.
+func (a Asset) Path() string {
+	return "/" + a.File
+}
+
+// Pipeline copies assets into the output directory: own JS and CSS files are minified, "HASH" in the destination is
+// replaced by the content hash, and files with unchanged content are not rewritten.
+type Pipeline struct {
+	outputDir string
+	minify    bool
+	record    func(filePath string) error // called for every written (or unchanged) file, e.g. for the build manifest
+	assets    map[string]*Asset
+}
+
+func NewPipeline(outputDir string, minify bool, record func(filePath string) error) *Pipeline {
+	return &Pipeline{outputDir: outputDir, minify: minify, record: record, assets: make(map[string]*Asset)}
+}
+
+// integrity returns the subresource integrity value of the content.
+func integrity(content []byte) string {
+	sum := sha512.Sum384(content)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Add copies the source file to dst (relative to the output directory) and registers it as the asset name; own JS and
+// CSS files are minified (third-party files are already minified).
+func (p *Pipeline) Add(name, src, dst string, own bool) (*Asset, error) {
+	if _, found := p.assets[name]; found {
+		return nil, fmt.Errorf("duplicate asset '%s'", name)
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	if own && p.minify {
+		content = Minify(src, content)
+	}
+
+	sum := sha256.Sum256(content)
+	file := strings.ReplaceAll(dst, "HASH", fmt.Sprintf("%.8x", sum[:]))
+	filePath := filepath.Join(p.outputDir, filepath.FromSlash(file))
+	if _, err := utils.WriteFileIfChanged(filePath, content); err != nil {
+		return nil, err
+	}
+	if p.record != nil {
+		if err := p.record(filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	asset := &Asset{Name: name, File: file, Integrity: integrity(content)}
+	p.assets[name] = asset
+	return asset, nil
+}
+
+// Get returns the asset with the logical name.
+func (p *Pipeline) Get(name string) (*Asset, error) {
+	if asset, found := p.assets[name]; found {
+		return asset, nil
+	}
+	return nil, fmt.Errorf("unknown asset '%s'", name)
+}
+
+// Manifest returns all assets, sorted by name.
+func (p *Pipeline) Manifest() []*Asset {
+	assets := make([]*Asset, 0, len(p.assets))
+	for _, asset := range p.assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Name < assets[j].Name
+	})
+	return assets
+}
+
+// SaveManifest writes the manifest as JSON (logical name -> asset).
+func (p *Pipeline) SaveManifest(filePath string) error {
+	buf, err := json.MarshalIndent(p.assets, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = utils.WriteFileIfChanged(filePath, buf)
+	return err
+}
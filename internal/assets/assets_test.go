@@ -0,0 +1,88 @@
+package assets
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	src := t.TempDir()
+	output := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "main.js"), []byte("// main\nconst a = 1;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "lib.js"), []byte("// third party\nconst b = 2;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := make([]string, 0)
+	p := NewPipeline(output, true, func(filePath string) error {
+		recorded = append(recorded, filePath)
+		return nil
+	})
+	main, err := p.Add("main.js", filepath.Join(src, "main.js"), "main-HASH.js", true)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	lib, err := p.Add("lib.js", filepath.Join(src, "lib.js"), "js/lib-HASH.js", false)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := p.Add("main.js", filepath.Join(src, "main.js"), "main-HASH.js", true); err == nil {
+		t.Fatalf("Add() of duplicate name: no error")
+	}
+
+	// own files are minified, third-party files are copied unchanged
+	content, err := os.ReadFile(filepath.Join(output, main.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "const a=1;\n" {
+		t.Fatalf("main.js = %q", content)
+	}
+	if !strings.HasPrefix(main.File, "main-") || len(main.File) != len("main-0123456789abcdef.js") || main.Path() != "/"+main.File {
+		t.Fatalf("unexpected file name %s", main.File)
+	}
+	sum := sha512.Sum384(content)
+	if want := "sha384-" + base64.StdEncoding.EncodeToString(sum[:]); main.Integrity != want {
+		t.Fatalf("Integrity = %s, want %s", main.Integrity, want)
+	}
+	if content, err := os.ReadFile(filepath.Join(output, lib.File)); err != nil || !strings.HasPrefix(string(content), "// third party") {
+		t.Fatalf("lib.js = %q, %v", content, err)
+	}
+	if len(recorded) != 2 || recorded[1] != filepath.Join(output, lib.File) {
+		t.Fatalf("recorded files = %v", recorded)
+	}
+
+	// lookup by logical name
+	if got, err := p.Get("lib.js"); err != nil || got != lib {
+		t.Fatalf("Get(lib.js) = %v, %v", got, err)
+	}
+	if _, err := p.Get("unknown.js"); err == nil {
+		t.Fatalf("Get(unknown.js): no error")
+	}
+	if manifest := p.Manifest(); len(manifest) != 2 || manifest[0] != lib || manifest[1] != main {
+		t.Fatalf("Manifest() = %v", manifest)
+	}
+
+	manifestFile := filepath.Join(t.TempDir(), "assets.json")
+	if err := p.SaveManifest(manifestFile); err != nil {
+		t.Fatalf("SaveManifest() error = %v", err)
+	}
+	buf, err := os.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]Asset
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		t.Fatalf("Unmarshal() error = %v\n%s", err, buf)
+	}
+	if saved["main.js"] != *main {
+		t.Fatalf("saved manifest = %+v", saved)
+	}
+}
@@ -0,0 +1,272 @@
+package assets
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// The minifiers are deliberately conservative: they remove comments and redundant whitespace, but never rewrite code.
+// Strings, template literals and regular expressions (JS), strings (CSS) and the content of pre, textarea, script and
+// style elements (HTML) are kept as they are.
+
+// Minify minifies JS, CSS and HTML content depending on the file extension; other content is returned unchanged.
+func Minify(fileName string, content []byte) []byte {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".js":
+		return MinifyJS(content)
+	case ".css":
+		return MinifyCSS(content)
+	case ".html":
+		return MinifyHTML(content)
+	}
+	return content
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// copyQuoted copies the string starting at src[i] (a quote character) including the closing quote; it returns the
+// index after the string.
+func copyQuoted(out *bytes.Buffer, src []byte, i int) int {
+	quote := src[i]
+	out.WriteByte(quote)
+	for i += 1; i < len(src); i += 1 {
+		out.WriteByte(src[i])
+		if src[i] == '\\' && i+1 < len(src) {
+			i += 1
+			out.WriteByte(src[i])
+		} else if src[i] == quote {
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipComment skips the block comment starting at src[i] ("/*"); it returns the index after the comment and whether
+// the comment contains a line break.
+func skipComment(src []byte, i int) (int, bool) {
+	end := bytes.Index(src[i+2:], []byte("*/"))
+	if end < 0 {
+		return len(src), bytes.IndexByte(src[i:], '\n') >= 0
+	}
+	comment := src[i : i+2+end+2]
+	return i + len(comment), bytes.IndexByte(comment, '\n') >= 0
+}
+
+// jsNeedsSpace returns true if removing the whitespace between a and b would join two tokens (e.g. "return x",
+// "a + +b", "a / /re/").
+func jsNeedsSpace(a, b byte) bool {
+	return (isIdent(a) && isIdent(b)) || (a == '+' && b == '+') || (a == '-' && b == '-') || (a == '/' && (b == '/' || b == '*'))
+}
+
+// jsRegexAllowed returns true if a "/" after the minified output starts a regular expression (and isn't a division).
+func jsRegexAllowed(out []byte) bool {
+	if len(out) == 0 {
+		return true
+	}
+	last := out[len(out)-1]
+	if strings.IndexByte("(,=:[!&|?{};+-*%<>~^\n", last) >= 0 {
+		return true
+	}
+	for _, keyword := range []string{"return", "typeof", "case", "do", "else", "in", "of", "void", "delete", "throw"} {
+		if bytes.HasSuffix(out, []byte(keyword)) && (len(out) == len(keyword) || !isIdent(out[len(out)-len(keyword)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinifyJS removes comments, indentation and redundant whitespace; line breaks are kept where automatic semicolon
+// insertion might depend on them.
+func MinifyJS(src []byte) []byte {
+	var out bytes.Buffer
+	pendingSpace, pendingNewline := false, false
+	flush := func(next byte) {
+		if out.Len() > 0 && (pendingSpace || pendingNewline) {
+			prev := out.Bytes()[out.Len()-1]
+			if pendingNewline && strings.IndexByte("{;,([", prev) < 0 && strings.IndexByte("}])", next) < 0 {
+				out.WriteByte('\n')
+			} else if jsNeedsSpace(prev, next) {
+				out.WriteByte(' ')
+			}
+		}
+		pendingSpace, pendingNewline = false, false
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case isSpace(c):
+			pendingSpace = true
+			pendingNewline = pendingNewline || c == '\n'
+			i += 1
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i += 1
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			var newline bool
+			i, newline = skipComment(src, i)
+			pendingSpace = true
+			pendingNewline = pendingNewline || newline
+		case c == '\'' || c == '"' || c == '`':
+			flush(c)
+			i = copyQuoted(&out, src, i)
+		case c == '/' && jsRegexAllowed(out.Bytes()):
+			flush(c)
+			out.WriteByte(c)
+			inClass := false
+			for i += 1; i < len(src) && src[i] != '\n'; i += 1 {
+				out.WriteByte(src[i])
+				if src[i] == '\\' && i+1 < len(src) {
+					i += 1
+					out.WriteByte(src[i])
+				} else if src[i] == '[' {
+					inClass = true
+				} else if src[i] == ']' {
+					inClass = false
+				} else if src[i] == '/' && !inClass {
+					i += 1
+					break
+				}
+			}
+		default:
+			flush(c)
+			out.WriteByte(c)
+			i += 1
+		}
+	}
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// MinifyCSS removes comments and redundant whitespace and semicolons.
+func MinifyCSS(src []byte) []byte {
+	var out bytes.Buffer
+	pendingSpace := false
+	depth := 0
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case isSpace(c):
+			pendingSpace = true
+			i += 1
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i, _ = skipComment(src, i)
+			pendingSpace = true
+			continue
+		}
+
+		if pendingSpace && out.Len() > 0 {
+			prev := out.Bytes()[out.Len()-1]
+			if strings.IndexByte("{};,>", prev) < 0 && strings.IndexByte("{};,>", c) < 0 && !(prev == ':' && depth > 0) {
+				out.WriteByte(' ')
+			}
+		}
+		pendingSpace = false
+
+		switch c {
+		case '"', '\'':
+			i = copyQuoted(&out, src, i)
+			continue
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+			// "a: b;}" -> "a: b}"
+			if out.Len() > 0 && out.Bytes()[out.Len()-1] == ';' {
+				out.Truncate(out.Len() - 1)
+			}
+		}
+		out.WriteByte(c)
+		i += 1
+	}
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// rawElements are the HTML elements whose content is kept unchanged.
+var rawElements = []string{"pre", "textarea", "script", "style"}
+
+// MinifyHTML removes comments and collapses whitespace between and within text; whitespace runs with line breaks
+// become a single line break, others a single space. Tags and attributes are kept unchanged.
+func MinifyHTML(src []byte) []byte {
+	var out bytes.Buffer
+	// ASCII-only, so that the indices of lower and src match
+	lower := make([]byte, len(src))
+	for i, c := range src {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case bytes.HasPrefix(src[i:], []byte("<!--")) && !bytes.HasPrefix(src[i:], []byte("<!--[if")):
+			end := bytes.Index(src[i+4:], []byte("-->"))
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += 4 + end + 3
+			}
+		case c == '<' && i+1 < len(src) && (src[i+1] == '/' || src[i+1] == '!' || isIdent(src[i+1])):
+			// copy the tag (quoted attribute values may contain ">")
+			start := i
+			for i += 1; i < len(src) && src[i] != '>'; i += 1 {
+				if src[i] == '"' || src[i] == '\'' {
+					if end := bytes.IndexByte(src[i+1:], src[i]); end >= 0 {
+						i += 1 + end
+					}
+				}
+			}
+			if i < len(src) {
+				i += 1
+			}
+			out.Write(src[start:i])
+
+			// copy the content of raw elements up to their end tag
+			name := lower[start+1 : i]
+			for _, raw := range rawElements {
+				if bytes.HasPrefix(name, []byte(raw)) && (len(name) == len(raw) || !isIdent(name[len(raw)])) {
+					end := bytes.Index(lower[i:], []byte("</"+raw))
+					if end < 0 {
+						end = len(src) - i
+					}
+					out.Write(src[i : i+end])
+					i += end
+					break
+				}
+			}
+		case isSpace(c):
+			newline := false
+			for i < len(src) && isSpace(src[i]) {
+				newline = newline || src[i] == '\n'
+				i += 1
+			}
+			if out.Len() == 0 {
+				continue
+			}
+			if newline {
+				out.WriteByte('\n')
+			} else {
+				out.WriteByte(' ')
+			}
+		default:
+			out.WriteByte(c)
+			i += 1
+		}
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,74 @@
+package assets
+
+import (
+	"testing"
+)
+
+func TestMinifyJS(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"comments", "// comment\nconst a = 1; /* block */ const b = 2;\n", "const a=1;const b=2;\n"},
+		{"indentation", "if (a) {\n    f(a);\n}\n", "if(a){f(a);}\n"},
+		{"keywords", "return typeof x;", "return typeof x;\n"},
+		{"asi", "let a = b\nlet c = d\n", "let a=b\nlet c=d\n"},
+		{"increments", "a + +b; c - -d; e++ + f", "a+ +b;c- -d;e++ +f\n"},
+		{"strings", "f('a  // b', \"c /* d */\", `${x}  /y/`);", "f('a  // b',\"c /* d */\",`${x}  /y/`);\n"},
+		{"regexp", "const re = /[/]  \\/ x/g; const y = a / b / c;", "const re=/[/]  \\/ x/g;const y=a/b/c;\n"},
+		{"regexp after return", "return /a b/.test(s);", "return/a b/.test(s);\n"},
+		{"empty", "  \n", ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(MinifyJS([]byte(tc.src))); got != tc.want {
+				t.Fatalf("MinifyJS(%q) = %q, want %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMinifyCSS(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"rules", "a, b {\n    color: red;\n    margin: 0 auto;\n}\n", "a,b{color:red;margin:0 auto}\n"},
+		{"comments", "/* header */\na { color: red; } /* x */", "a{color:red}\n"},
+		{"selectors", ".a :hover > .b [role=button] { padding: 0px 0px; }", ".a :hover>.b [role=button]{padding:0px 0px}\n"},
+		{"media", "@media screen and (max-width: 1023px) {\n    a { top: calc(1px + 2px) !important; }\n}", "@media screen and (max-width: 1023px){a{top:calc(1px + 2px) !important}}\n"},
+		{"strings", "a::before { content: \"a  ;  }\"; }", "a::before{content:\"a  ;  }\"}\n"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(MinifyCSS([]byte(tc.src))); got != tc.want {
+				t.Fatalf("MinifyCSS(%q) = %q, want %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMinifyHTML(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"whitespace", "<p>\n    Hallo   <b>Welt</b>\n</p>\n", "<p>\nHallo <b>Welt</b>\n</p>\n"},
+		{"comments", "<p>a<!-- comment -->b</p><!--[if IE]>x<![endif]-->", "<p>ab</p><!--[if IE]>x<![endif]-->"},
+		{"attributes", "<a title=\"a  >  b\"   href=\"/\">x</a>", "<a title=\"a  >  b\"   href=\"/\">x</a>"},
+		{"raw elements", "<pre>a\n    b</pre>\n<script>\n  if (a  <  b) {}\n</script>  <STYLE>a  { }</STYLE>", "<pre>a\n    b</pre>\n<script>\n  if (a  <  b) {}\n</script> <STYLE>a  { }</STYLE>"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(MinifyHTML([]byte(tc.src))); got != tc.want {
+				t.Fatalf("MinifyHTML(%q) = %q, want %q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
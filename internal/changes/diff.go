@@ -0,0 +1,115 @@
+package changes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+const (
+	KindNewEvent     = "new-event"
+	KindStatus       = "status"
+	KindCancellation = "cancellation"
+	KindNewArticle   = "new-article"
+	KindRenamed      = "renamed"
+	KindMoved        = "moved"
+)
+
+// Change is a single difference between two snapshots.
+type Change struct {
+	Kind  string
+	Id    string // event id or article slug
+	Key   string // unique key of the change, e.g. the cancellation date
+	Title string
+	Text  string
+}
+
+func statusLabel(status string) string {
+	if s, err := parkrun.ParseStatus(status); err == nil {
+		return s.Label()
+	}
+	return status
+}
+
+func formatDate(s string) string {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Format("02.01.2006")
+	}
+	return s
+}
+
+// Diff returns the changes from prev to cur: new events, status transitions, new cancellations, new articles and
+// events that have been renamed or moved in events.json (if both snapshots contain events.json).
+func Diff(prev, cur Snapshot) []Change {
+	changes := make([]Change, 0)
+
+	// renamed and moved events of events.json
+	renamedTo := make(map[string]bool)
+	if len(prev.EventsJson) > 0 && len(cur.EventsJson) > 0 {
+		r := parkrun.Reconcile(prev.EventsJson, cur.EventsJson, nil, parkrun.DefaultMoveThreshold)
+		for _, rename := range r.Renamed {
+			renamedTo[rename.NewId] = true
+			if rename.OldLongName != rename.NewLongName {
+				changes = append(changes, Change{KindRenamed, rename.NewId, rename.NewLongName, fmt.Sprintf("Umbenannt: %s heißt jetzt %s", rename.OldLongName, rename.NewLongName),
+					fmt.Sprintf("Der %s heißt jetzt %s.", rename.OldLongName, rename.NewLongName)})
+			} else {
+				changes = append(changes, Change{KindRenamed, rename.NewId, rename.NewId, fmt.Sprintf("%s: neue Adresse", rename.NewLongName),
+					fmt.Sprintf("Die Seite des %s hat eine neue Adresse (%s statt %s).", rename.NewLongName, rename.NewId, rename.OldId)})
+			}
+		}
+		for _, move := range r.Moved {
+			changes = append(changes, Change{KindMoved, move.Id, fmt.Sprintf("%.5f,%.5f", move.To.Lat, move.To.Lon), fmt.Sprintf("Neuer Startpunkt: %s", move.LongName),
+				fmt.Sprintf("Der Startpunkt des %s wurde um %.0f m verlegt.", move.LongName, move.DistanceMeters)})
+		}
+	}
+
+	prevEvents := make(map[string]EventState)
+	for _, e := range prev.Events {
+		prevEvents[e.Id] = e
+	}
+
+	for _, e := range cur.Events {
+		p, found := prevEvents[e.Id]
+		if !found {
+			if renamedTo[e.Id] {
+				continue
+			}
+			title := fmt.Sprintf("Neuer parkrun: %s", e.Name)
+			if e.Status != "" {
+				title = fmt.Sprintf("Neuer parkrun: %s (%s)", e.Name, e.Status)
+			}
+			changes = append(changes, Change{KindNewEvent, e.Id, e.Status, title, fmt.Sprintf("Der %s wurde neu aufgenommen.", e.Name)})
+			continue
+		}
+
+		if p.Status != e.Status {
+			changes = append(changes, Change{KindStatus, e.Id, e.Status, fmt.Sprintf("%s: %s", e.Name, statusLabel(e.Status)),
+				fmt.Sprintf("Der Status des %s hat sich von '%s' zu '%s' geändert.", e.Name, statusLabel(p.Status), statusLabel(e.Status))})
+		}
+
+		known := make(map[string]struct{})
+		for _, c := range p.Cancellations {
+			known[c.Date] = struct{}{}
+		}
+		for _, c := range e.Cancellations {
+			if _, found := known[c.Date]; found {
+				continue
+			}
+			changes = append(changes, Change{KindCancellation, e.Id, c.Date, fmt.Sprintf("Absage: %s am %s", e.Name, formatDate(c.Date)),
+				fmt.Sprintf("Der %s fällt am %s aus (%s).", e.Name, formatDate(c.Date), c.Reason)})
+		}
+	}
+
+	prevArticles := make(map[string]struct{})
+	for _, a := range prev.Articles {
+		prevArticles[a.Slug] = struct{}{}
+	}
+	for _, a := range cur.Articles {
+		if _, found := prevArticles[a.Slug]; !found {
+			changes = append(changes, Change{KindNewArticle, a.Slug, "", fmt.Sprintf("Neuer Artikel: %s", a.Title), a.Title})
+		}
+	}
+
+	return changes
+}
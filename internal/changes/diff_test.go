@@ -0,0 +1,133 @@
+package changes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+func TestDiff(t *testing.T) {
+	prev := Snapshot{
+		Events: []EventState{
+			{Id: "dietenbach", Name: "Dietenbach parkrun", Status: "", Cancellations: []CancellationState{{Date: "2026-05-30", Reason: "Wetter"}}},
+			{Id: "stadtparkrotehorn", Name: "Stadtpark Rotehorn parkrun", Status: "geplant"},
+			{Id: "kiessee", Name: "Kiessee parkrun", Status: ""},
+		},
+		Articles: []ArticleState{{Slug: "course-design", Title: "Streckendesign"}},
+	}
+	cur := Snapshot{
+		Events: []EventState{
+			{Id: "dietenbach", Name: "Dietenbach parkrun", Status: "", Cancellations: []CancellationState{{Date: "2026-05-30", Reason: "Wetter"}, {Date: "2026-06-06", Reason: "Wetter"}}},
+			{Id: "stadtparkrotehorn", Name: "Stadtpark Rotehorn parkrun", Status: ""},
+			{Id: "kiessee", Name: "Kiessee parkrun", Status: "temporär geschlossen"},
+			{Id: "neuerpark", Name: "Neuer Park parkrun", Status: "geplant"},
+		},
+		Articles: []ArticleState{{Slug: "course-design", Title: "Streckendesign"}, {Slug: "helferrollen", Title: "Helferrollen"}},
+	}
+
+	got := Diff(prev, cur)
+	want := []struct {
+		kind string
+		id   string
+		key  string
+	}{
+		{KindCancellation, "dietenbach", "2026-06-06"},
+		{KindStatus, "stadtparkrotehorn", ""},
+		{KindStatus, "kiessee", "temporär geschlossen"},
+		{KindNewEvent, "neuerpark", "geplant"},
+		{KindNewArticle, "helferrollen", ""},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Diff() returned %d changes, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Id != w.id || got[i].Key != w.key {
+			t.Fatalf("Diff() change %d = %s/%s/%s, want %s/%s/%s", i, got[i].Kind, got[i].Id, got[i].Key, w.kind, w.id, w.key)
+		}
+	}
+
+	if unchanged := Diff(cur, cur); len(unchanged) != 0 {
+		t.Fatalf("Diff() of identical snapshots returned %d changes: %v", len(unchanged), unchanged)
+	}
+}
+
+func TestDiffEventsJson(t *testing.T) {
+	prev := Snapshot{
+		Events: []EventState{{Id: "dietenbach"}, {Id: "seepark"}, {Id: "kiessee"}},
+		EventsJson: []parkrun.EventsJsonEntry{
+			{Id: "dietenbach", LongName: "Dietenbach parkrun", Lat: 47.99, Lon: 7.79},
+			{Id: "kiessee", LongName: "Kiessee parkrun", Lat: 51.52, Lon: 9.93},
+			{Id: "seepark", LongName: "Seepark parkrun", Lat: 48.01, Lon: 7.82},
+		},
+	}
+	cur := Snapshot{
+		Events: []EventState{{Id: "dietenbach"}, {Id: "seepark-freiburg"}, {Id: "kiessee"}},
+		EventsJson: []parkrun.EventsJsonEntry{
+			{Id: "dietenbach", LongName: "Dietenbachsee parkrun", Lat: 47.99, Lon: 7.79},
+			{Id: "kiessee", LongName: "Kiessee parkrun", Lat: 51.53, Lon: 9.93},
+			{Id: "seepark-freiburg", LongName: "Seepark parkrun", Lat: 48.01, Lon: 7.82},
+		},
+	}
+
+	got := Diff(prev, cur)
+	want := []struct {
+		kind string
+		id   string
+	}{
+		{KindRenamed, "dietenbach"},
+		{KindRenamed, "seepark-freiburg"},
+		{KindMoved, "kiessee"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Diff() returned %d changes, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Id != w.id {
+			t.Fatalf("Diff() change %d = %s/%s, want %s/%s", i, got[i].Kind, got[i].Id, w.kind, w.id)
+		}
+	}
+
+	// snapshots of older builds don't contain events.json
+	prev.EventsJson = nil
+	if got := Diff(prev, cur); len(got) != 1 || got[0].Kind != KindNewEvent {
+		t.Fatalf("Diff() without previous events.json = %v", got)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	prev := Snapshot{
+		Events: []EventState{
+			{Id: "dietenbach", Name: "Dietenbach parkrun", Lat: 48.0, Lon: 7.8, LatestRun: &RunState{Index: 10, Date: "2026-05-23", Runners: 100}},
+			{Id: "kurpark", Name: "Kurpark parkrun", Status: "archiviert"},
+		},
+	}
+	cur := Snapshot{
+		Events: []EventState{
+			{Id: "dietenbach", Name: "Dietenbach parkrun", Lat: 48.001, Lon: 7.8, LatestRun: &RunState{Index: 11, Date: "2026-05-30", Runners: 120},
+				Links: []LinkState{{Name: "Instagram", Url: "https://www.instagram.com/dietenbachparkrun/"}}},
+			{Id: "neuerpark", Name: "Neuer Park parkrun", Status: "geplant"},
+		},
+	}
+
+	var sb strings.Builder
+	if err := WriteReport(&sb, prev, cur); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	report := sb.String()
+
+	for _, want := range []string{
+		"+ neuerpark: Neuer Park parkrun (geplant)\n",
+		"- kurpark: Kurpark parkrun (archiviert)\n",
+		"~ dietenbach: Dietenbach parkrun\n",
+		"    coordinates: moved by 111m to 48.00100,7.80000\n",
+		"    latest run: #10 am 23.05.2026, 100 Teilnehmer -> #11 am 30.05.2026, 120 Teilnehmer\n",
+		"    link added: Instagram https://www.instagram.com/dietenbachparkrun/\n",
+		"1 events added, 1 removed, 1 changed\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("missing %q in report:\n%s", want, report)
+		}
+	}
+}
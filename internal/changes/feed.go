@@ -0,0 +1,136 @@
+package changes
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+const maxFeedEntries = 200
+
+type FeedEntry struct {
+	Id      string    `json:"id"`
+	Title   string    `json:"title"`
+	Summary string    `json:"summary"`
+	Link    string    `json:"link"`
+	Updated time.Time `json:"updated"`
+}
+
+// Feed is the list of all change entries, newest first; it is persisted between builds.
+type Feed struct {
+	Entries []FeedEntry `json:"entries"`
+}
+
+// LoadFeed reads the feed entries from a JSON file; a missing file results in an empty feed.
+func LoadFeed(filePath string) (*Feed, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Feed{}, nil
+		}
+		return nil, fmt.Errorf("while reading feed %s: %w", filePath, err)
+	}
+
+	feed := &Feed{}
+	if err := json.Unmarshal(buf, feed); err != nil {
+		return nil, fmt.Errorf("while parsing feed %s: %w", filePath, err)
+	}
+	return feed, nil
+}
+
+func (feed Feed) Save(filePath string) error {
+	buf, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, buf)
+}
+
+// Add prepends entries for the given changes; link maps a change to the URL of the affected page.
+func (feed *Feed) Add(changes []Change, domain string, now time.Time, link func(Change) string) {
+	entries := make([]FeedEntry, 0, len(changes)+len(feed.Entries))
+	for _, change := range changes {
+		entries = append(entries, FeedEntry{
+			Id:      fmt.Sprintf("tag:%s,%s:%s/%s/%s", domain, now.Format("2006-01-02"), change.Kind, change.Id, change.Key),
+			Title:   change.Title,
+			Summary: change.Text,
+			Link:    link(change),
+			Updated: now,
+		})
+	}
+	entries = append(entries, feed.Entries...)
+	if len(entries) > maxFeedEntries {
+		entries = entries[:maxFeedEntries]
+	}
+	feed.Entries = entries
+}
+
+// WriteAtom writes the feed as Atom XML.
+func (feed Feed) WriteAtom(filePath string, title string, siteUrl string, now time.Time) error {
+	type atomLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+		Type string `xml:"type,attr,omitempty"`
+	}
+
+	type atomEntry struct {
+		Id      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Link    atomLink `xml:"link"`
+		Summary string   `xml:"summary"`
+	}
+
+	type atomAuthor struct {
+		Name string `xml:"name"`
+	}
+
+	type atomFeed struct {
+		XMLName xml.Name    `xml:"feed"`
+		Xmlns   string      `xml:"xmlns,attr"`
+		Id      string      `xml:"id"`
+		Title   string      `xml:"title"`
+		Updated string      `xml:"updated"`
+		Author  atomAuthor  `xml:"author"`
+		Links   []atomLink  `xml:"link"`
+		Entries []atomEntry `xml:"entry"`
+	}
+
+	updated := now
+	if len(feed.Entries) > 0 {
+		updated = feed.Entries[0].Updated
+	}
+
+	atom := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Id:      siteUrl + "/",
+		Title:   title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: title},
+		Links: []atomLink{
+			{Href: siteUrl + "/feed.xml", Rel: "self", Type: "application/atom+xml"},
+			{Href: siteUrl + "/", Rel: "alternate", Type: "text/html"},
+		},
+		Entries: make([]atomEntry, 0, len(feed.Entries)),
+	}
+	for _, entry := range feed.Entries {
+		atom.Entries = append(atom.Entries, atomEntry{
+			Id:      entry.Id,
+			Title:   entry.Title,
+			Updated: entry.Updated.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: entry.Link},
+			Summary: entry.Summary,
+		})
+	}
+
+	buf, err := xml.MarshalIndent(atom, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, append([]byte(xml.Header), buf...))
+}
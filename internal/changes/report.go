@@ -0,0 +1,149 @@
+package changes
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+func (run *RunState) String() string {
+	if run == nil {
+		return "-"
+	}
+	return fmt.Sprintf("#%d am %s, %d Teilnehmer", run.Index, formatDate(run.Date), run.Runners)
+}
+
+func (e EventState) hasCoordinates() bool {
+	return e.Lat != 0 || e.Lon != 0
+}
+
+// eventDifferences returns a human-readable line for each field that differs between prev and cur.
+func eventDifferences(prev, cur EventState) []string {
+	lines := make([]string, 0)
+	field := func(name, p, c string) {
+		if p != c {
+			lines = append(lines, fmt.Sprintf("%s: '%s' -> '%s'", name, p, c))
+		}
+	}
+
+	field("name", prev.Name, cur.Name)
+	field("status", statusLabel(prev.Status), statusLabel(cur.Status))
+	field("location", prev.Location, cur.Location)
+	field("state", prev.State, cur.State)
+	field("first", prev.First, cur.First)
+	field("url", prev.Url, cur.Url)
+	field("course", prev.CourseId, cur.CourseId)
+
+	if prev.hasCoordinates() != cur.hasCoordinates() {
+		lines = append(lines, fmt.Sprintf("coordinates: %.5f,%.5f -> %.5f,%.5f", prev.Lat, prev.Lon, cur.Lat, cur.Lon))
+	} else if prev.hasCoordinates() {
+		distance := utils.DistanceMeters(utils.Coordinates{Lat: prev.Lat, Lon: prev.Lon}, utils.Coordinates{Lat: cur.Lat, Lon: cur.Lon})
+		if distance >= 1 {
+			lines = append(lines, fmt.Sprintf("coordinates: moved by %.0fm to %.5f,%.5f", distance, cur.Lat, cur.Lon))
+		}
+	}
+
+	if prev.LatestRun.String() != cur.LatestRun.String() {
+		lines = append(lines, fmt.Sprintf("latest run: %s -> %s", prev.LatestRun.String(), cur.LatestRun.String()))
+	}
+	if prev.Current != cur.Current {
+		lines = append(lines, fmt.Sprintf("current: %v -> %v", prev.Current, cur.Current))
+	}
+
+	prevLinks := make(map[LinkState]struct{})
+	for _, link := range prev.Links {
+		prevLinks[link] = struct{}{}
+	}
+	curLinks := make(map[LinkState]struct{})
+	for _, link := range cur.Links {
+		curLinks[link] = struct{}{}
+		if _, found := prevLinks[link]; !found {
+			lines = append(lines, fmt.Sprintf("link added: %s %s", link.Name, link.Url))
+		}
+	}
+	for _, link := range prev.Links {
+		if _, found := curLinks[link]; !found {
+			lines = append(lines, fmt.Sprintf("link removed: %s %s", link.Name, link.Url))
+		}
+	}
+
+	prevCancellations := make(map[string]struct{})
+	for _, c := range prev.Cancellations {
+		prevCancellations[c.Date] = struct{}{}
+	}
+	curCancellations := make(map[string]struct{})
+	for _, c := range cur.Cancellations {
+		curCancellations[c.Date] = struct{}{}
+		if _, found := prevCancellations[c.Date]; !found {
+			lines = append(lines, fmt.Sprintf("cancellation added: %s (%s)", formatDate(c.Date), c.Reason))
+		}
+	}
+	for _, c := range prev.Cancellations {
+		if _, found := curCancellations[c.Date]; !found {
+			lines = append(lines, fmt.Sprintf("cancellation removed: %s (%s)", formatDate(c.Date), c.Reason))
+		}
+	}
+
+	return lines
+}
+
+// WriteReport writes a human-readable report of all differences between the snapshots prev and cur.
+func WriteReport(w io.Writer, prev, cur Snapshot) error {
+	var sb strings.Builder
+	added, removed, changed := 0, 0, 0
+
+	prevEvents := make(map[string]EventState)
+	for _, e := range prev.Events {
+		prevEvents[e.Id] = e
+	}
+	curEvents := make(map[string]EventState)
+	for _, e := range cur.Events {
+		curEvents[e.Id] = e
+	}
+
+	for _, e := range cur.Events {
+		p, found := prevEvents[e.Id]
+		if !found {
+			added += 1
+			fmt.Fprintf(&sb, "+ %s: %s (%s)\n", e.Id, e.Name, statusLabel(e.Status))
+			continue
+		}
+		if lines := eventDifferences(p, e); len(lines) > 0 {
+			changed += 1
+			fmt.Fprintf(&sb, "~ %s: %s\n", e.Id, e.Name)
+			for _, line := range lines {
+				fmt.Fprintf(&sb, "    %s\n", line)
+			}
+		}
+	}
+	for _, e := range prev.Events {
+		if _, found := curEvents[e.Id]; !found {
+			removed += 1
+			fmt.Fprintf(&sb, "- %s: %s (%s)\n", e.Id, e.Name, statusLabel(e.Status))
+		}
+	}
+
+	prevArticles := make(map[string]struct{})
+	for _, a := range prev.Articles {
+		prevArticles[a.Slug] = struct{}{}
+	}
+	curArticles := make(map[string]struct{})
+	for _, a := range cur.Articles {
+		curArticles[a.Slug] = struct{}{}
+		if _, found := prevArticles[a.Slug]; !found {
+			fmt.Fprintf(&sb, "+ article %s: %s\n", a.Slug, a.Title)
+		}
+	}
+	for _, a := range prev.Articles {
+		if _, found := curArticles[a.Slug]; !found {
+			fmt.Fprintf(&sb, "- article %s: %s\n", a.Slug, a.Title)
+		}
+	}
+
+	fmt.Fprintf(&sb, "%d events added, %d removed, %d changed\n", added, removed, changed)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,130 @@
+package changes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+type CancellationState struct {
+	Date   string `json:"date"`
+	Reason string `json:"reason"`
+}
+
+type RunState struct {
+	Index   int    `json:"index"`
+	Date    string `json:"date"`
+	Runners int    `json:"runners"`
+}
+
+type LinkState struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type EventState struct {
+	Id            string              `json:"id"`
+	Name          string              `json:"name"`
+	Status        string              `json:"status"`
+	Location      string              `json:"location,omitempty"`
+	State         string              `json:"state,omitempty"`
+	Lat           float64             `json:"lat,omitempty"`
+	Lon           float64             `json:"lon,omitempty"`
+	First         string              `json:"first,omitempty"`
+	LatestRun     *RunState           `json:"latest_run,omitempty"`
+	Current       bool                `json:"current,omitempty"`
+	Url           string              `json:"url,omitempty"`
+	CourseId      string              `json:"course_id,omitempty"`
+	Links         []LinkState         `json:"links,omitempty"`
+	Cancellations []CancellationState `json:"cancellations"`
+}
+
+type ArticleState struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// Snapshot is the machine-readable state of a build (events with their derived fields, articles) that is compared against other builds.
+type Snapshot struct {
+	Events     []EventState              `json:"events"`
+	Articles   []ArticleState            `json:"articles"`
+	EventsJson []parkrun.EventsJsonEntry `json:"events_json,omitempty"` // parkrun's events.json, to detect renamed and moved events
+}
+
+func NewEventState(event *parkrun.Event) EventState {
+	cancellations := make([]CancellationState, 0, len(event.Cancellations))
+	for _, c := range event.Cancellations {
+		cancellations = append(cancellations, CancellationState{c.Date.Format("2006-01-02"), c.ReasonGerman()})
+	}
+	sort.Slice(cancellations, func(i, j int) bool {
+		return cancellations[i].Date < cancellations[j].Date
+	})
+
+	links := make([]LinkState, 0)
+	for _, link := range event.Links() {
+		links = append(links, LinkState{link.Name, link.Url})
+	}
+
+	state := EventState{
+		Id:            event.Id,
+		Name:          event.FixedName(),
+		Status:        string(event.Status),
+		Location:      event.FixedLocation(),
+		State:         event.State(),
+		First:         event.First(),
+		Current:       event.Current,
+		Url:           event.Url(),
+		CourseId:      event.GoogleMapsCourseId(),
+		Links:         links,
+		Cancellations: cancellations,
+	}
+	if event.Coords.IsValid() {
+		state.Lat = event.Coords.Lat
+		state.Lon = event.Coords.Lon
+	}
+	if event.LatestRun != nil {
+		state.LatestRun = &RunState{event.LatestRun.Index, event.LatestRun.Date.Format("2006-01-02"), event.LatestRun.RunnerCount}
+	}
+	return state
+}
+
+func NewSnapshot(events []*parkrun.Event, articles []ArticleState) Snapshot {
+	snapshot := Snapshot{
+		Events:   make([]EventState, 0, len(events)),
+		Articles: articles,
+	}
+	for _, event := range events {
+		snapshot.Events = append(snapshot.Events, NewEventState(event))
+	}
+	return snapshot
+}
+
+// LoadSnapshot reads a snapshot from a JSON file; a missing file is no error and results in a nil snapshot.
+func LoadSnapshot(filePath string) (*Snapshot, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while reading snapshot %s: %w", filePath, err)
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(buf, snapshot); err != nil {
+		return nil, fmt.Errorf("while parsing snapshot %s: %w", filePath, err)
+	}
+	return snapshot, nil
+}
+
+func (snapshot Snapshot) Save(filePath string) error {
+	buf, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, buf)
+}
@@ -0,0 +1,112 @@
+package datasource
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// CsvFile loads the data from local CSV files with the same columns as the Google Sheets ('data' and 'planned').
+// The column order is arbitrary, missing columns are treated as empty; both ',' and ';' are accepted as separators.
+type CsvFile struct {
+	Path        string
+	PlannedPath string
+}
+
+func readCsv(filePath string) ([][]string, error) {
+	buf, err := utils.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(buf))
+	firstLine, _, _ := strings.Cut(string(buf), "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filePath, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("parsing %s: missing header", filePath)
+	}
+	return rows, nil
+}
+
+// csvHeader maps the expected column names to their index in the header row (-1 if the column is missing).
+func csvHeader(header []string, names []string, required string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for _, name := range names {
+		columns[name] = -1
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, found := columns[name]; found {
+			columns[name] = i
+		}
+	}
+	if columns[required] < 0 {
+		return nil, fmt.Errorf("required column '%s' not found", required)
+	}
+	return columns, nil
+}
+
+func (source CsvFile) Load() (map[string]*parkrun.ParkrunInfo, []parkrun.PlannedData, error) {
+	rows, err := readCsv(source.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, err := csvHeader(rows[0], dataColumns, "id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("extracting header from %s: %w", source.Path, err)
+	}
+	addOptionalColumns(columns, rows[0], optionalDataColumns)
+	parkrunInfos, err := parseDataRows(columns, rows[1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", source.Path, err)
+	}
+
+	plannedData := make([]parkrun.PlannedData, 0)
+	if source.PlannedPath != "" {
+		rows, err := readCsv(source.PlannedPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns, err := csvHeader(rows[0], plannedColumns, "name")
+		if err != nil {
+			return nil, nil, fmt.Errorf("extracting header from %s: %w", source.PlannedPath, err)
+		}
+		plannedData, err = parsePlannedRows(columns, rows[1:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", source.PlannedPath, err)
+		}
+	}
+
+	return parkrunInfos, plannedData, nil
+}
+
+func (source CsvFile) LoadRows() (*Table, *Table, error) {
+	rows, err := readCsv(source.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := newTable("data", rows)
+
+	planned := &Table{Name: "planned"}
+	if source.PlannedPath != "" {
+		rows, err := readCsv(source.PlannedPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		planned = newTable("planned", rows)
+	}
+
+	return data, planned, nil
+}
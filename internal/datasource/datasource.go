@@ -0,0 +1,205 @@
+package datasource
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// DataSource provides the manually maintained parkrun data (per-event infos and planned parkruns).
+type DataSource interface {
+	// Load returns the validated infos (by id) and the planned parkruns.
+	Load() (map[string]*parkrun.ParkrunInfo, []parkrun.PlannedData, error)
+	// LoadRows returns the raw 'data' and 'planned' tables without any validation.
+	LoadRows() (*Table, *Table, error)
+}
+
+var dataColumns = []string{
+	"id", "updated", "name", "city", "state", "location", "description", "status", "first", "coordinates",
+	"route_type", "google_route_id", "google_maps_url",
+	"instagram", "facebook", "strava-club", "strava-segment",
+	"link1", "link2", "link3", "link4", "link5"}
+
+// columns that may be missing in the 'data' table
+var optionalDataColumns = []string{"cafe", "cafe_google_maps_url", "cafe_coordinates", "archived"}
+
+var plannedColumns = []string{"name", "city", "state", "status", "added", "start", "instagram", "link1"}
+
+// addOptionalColumns adds the indexes of the given optional columns in the header row (-1 if the column is missing).
+func addOptionalColumns(columns map[string]int, header []string, names []string) {
+	for _, name := range names {
+		columns[name] = -1
+		for i, h := range header {
+			if strings.ToLower(strings.TrimSpace(h)) == name {
+				columns[name] = i
+				break
+			}
+		}
+	}
+}
+
+func val(cols map[string]int, row []string, name string) string {
+	idx, found := cols[name]
+	if !found {
+		panic(fmt.Sprintf("column %s not found", name))
+	}
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return row[idx]
+}
+
+// parseDataRows converts the rows of the 'data' table (sheet or CSV, without header) to ParkrunInfos.
+func parseDataRows(columns map[string]int, rows [][]string) (map[string]*parkrun.ParkrunInfo, error) {
+	parkrunInfos := make(map[string]*parkrun.ParkrunInfo)
+	for i, row := range rows {
+		id := val(columns, row, "id")
+		var updated time.Time
+		if columns["updated"] >= 0 {
+			// the column may only be missing in hand-written CSV files
+			updatedStr := val(columns, row, "updated")
+			t, err := time.Parse("2006-01-02", updatedStr)
+			if err != nil {
+				return nil, fmt.Errorf("parsing updated date in row %d: %w", i+2, err)
+			}
+			updated = t
+		}
+		name := val(columns, row, "name")
+		city := val(columns, row, "city")
+		state := val(columns, row, "state")
+		location := val(columns, row, "location")
+		description := val(columns, row, "description")
+		routeType := val(columns, row, "route_type")
+		routeId := val(columns, row, "google_route_id")
+		googleMaps := val(columns, row, "google_maps_url")
+		first := val(columns, row, "first")
+		if first == "?" {
+			first = "-"
+		}
+		status, err := parkrun.ParseStatus(val(columns, row, "status"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing status in row %d: %w", i+2, err)
+		}
+		var archived time.Time
+		if archivedStr := strings.TrimSpace(val(columns, row, "archived")); archivedStr != "" {
+			t, err := time.Parse("2006-01-02", archivedStr)
+			if err != nil {
+				return nil, fmt.Errorf("parsing archived date in row %d: %w", i+2, err)
+			}
+			archived = t
+		}
+		coordinates := val(columns, row, "coordinates")
+		instagram := val(columns, row, "instagram")
+		facebook := val(columns, row, "facebook")
+		stravaClub := val(columns, row, "strava-club")
+		stravaSegment := val(columns, row, "strava-segment")
+
+		links := make([]parkrun.Link, 0)
+		if instagram != "" {
+			links = append(links, parkrun.Link{
+				Name: "Instagram",
+				Url:  instagram,
+			})
+		}
+		if facebook != "" {
+			links = append(links, parkrun.Link{
+				Name: "Facebook",
+				Url:  facebook,
+			})
+		}
+		if stravaClub != "" {
+			links = append(links, parkrun.Link{
+				Name: "Strava Club",
+				Url:  stravaClub,
+			})
+		}
+		if stravaSegment != "" {
+			links = append(links, parkrun.Link{
+				Name: "Strava Segment",
+				Url:  stravaSegment,
+			})
+		}
+		for j := 1; j <= 5; j++ {
+			linkStr := val(columns, row, fmt.Sprintf("link%d", j))
+			if linkStr != "" {
+				link, err := parkrun.ParseLink(linkStr)
+				if err != nil {
+					return nil, fmt.Errorf("parsing link in row %d: %w", i+2, err)
+				}
+				links = append(links, link)
+			}
+		}
+
+		var cafe *parkrun.Cafe
+		if cafeName := val(columns, row, "cafe"); cafeName != "" {
+			cafeCoordinates, err := utils.ParseCoordinates(val(columns, row, "cafe_coordinates"))
+			if err != nil {
+				return nil, fmt.Errorf("parsing cafe coordinates in row %d: %w", i+2, err)
+			}
+			cafe = &parkrun.Cafe{
+				Name:        cafeName,
+				GoogleMaps:  val(columns, row, "cafe_google_maps_url"),
+				Coordinates: cafeCoordinates,
+			}
+		}
+
+		parkrunInfos[id] = &parkrun.ParkrunInfo{
+			Id:          id,
+			Name:        name,
+			City:        city,
+			State:       state,
+			Location:    location,
+			Description: description,
+			RouteType:   routeType,
+			RouteID:     routeId,
+			GoogleMaps:  googleMaps,
+			Updated:     updated,
+			First:       first,
+			Status:      status,
+			Archived:    archived,
+			Coordinates: coordinates,
+			Links:       links,
+			Cafe:        cafe,
+		}
+	}
+
+	return parkrunInfos, nil
+}
+
+// parsePlannedRows converts the rows of the 'planned' table (sheet or CSV, without header) to PlannedData.
+func parsePlannedRows(columns map[string]int, rows [][]string) ([]parkrun.PlannedData, error) {
+	plannedData := make([]parkrun.PlannedData, 0)
+	for i, row := range rows {
+		link1, err := parkrun.ParseLink(val(columns, row, "link1"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing link1 in planned sheet: %w", err)
+		}
+		status, err := parkrun.ParsePlannedStatus(val(columns, row, "status"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing status in row %d of planned sheet: %w", i+2, err)
+		}
+		added, err := parkrun.ParsePlannedDate(val(columns, row, "added"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing added date in row %d of planned sheet: %w", i+2, err)
+		}
+		start, err := parkrun.ParsePlannedDate(val(columns, row, "start"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing start date in row %d of planned sheet: %w", i+2, err)
+		}
+		plannedData = append(plannedData, parkrun.PlannedData{
+			Name:      val(columns, row, "name"),
+			City:      val(columns, row, "city"),
+			State:     val(columns, row, "state"),
+			Status:    status,
+			Added:     added,
+			Start:     start,
+			Instagram: val(columns, row, "instagram"),
+			Link1:     link1,
+		})
+	}
+
+	return plannedData, nil
+}
@@ -0,0 +1,117 @@
+package datasource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJsonFileLoad(t *testing.T) {
+	infos, planned, err := JsonFile{Path: "../../data/parkruns.json"}.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(planned) != 0 {
+		t.Fatalf("expected no planned data, got %d", len(planned))
+	}
+
+	info, found := infos["aachenerweiher"]
+	if !found {
+		t.Fatalf("missing info for 'aachenerweiher'")
+	}
+	if info.Name != "Aachener Weiher parkrun" || info.City != "Köln" || info.State != "NRW" || info.First != "23.03.2019" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.RouteID != "1f0MCdzKQ19Wb8U7a9a1G2AmazMJfdmjw" {
+		t.Fatalf("unexpected route id: %s", info.RouteID)
+	}
+	if len(info.Links) != 5 {
+		t.Fatalf("expected 5 links, got %d: %v", len(info.Links), info.Links)
+	}
+	if info.Links[3].Name != "Strava Segment" || info.Links[3].Url != "https://www.strava.com/segments/20101656" {
+		t.Fatalf("unexpected strava link: %+v", info.Links[3])
+	}
+	if info.Cafe == nil || info.Cafe.Name != "KAWA COFFEE SNACKS & CO" || info.Cafe.Url() != "https://maps.app.goo.gl/jBGfGnq46FfBXm8L7" {
+		t.Fatalf("unexpected cafe: %+v", info.Cafe)
+	}
+
+	if infos["allerpark"].Cafe != nil {
+		t.Fatalf("unexpected cafe for 'allerpark': %+v", infos["allerpark"].Cafe)
+	}
+}
+
+func TestJsonFileLoadMatchesLoadRows(t *testing.T) {
+	source := JsonFile{Path: "../../data/parkruns.json"}
+	infos, _, err := source.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	data, _, err := source.LoadRows()
+	if err != nil {
+		t.Fatalf("LoadRows() error = %v", err)
+	}
+
+	for _, row := range data.Rows {
+		id := row.Values["id"]
+		info, found := infos[id]
+		if !found {
+			t.Errorf("row %d: missing info for '%s'", row.Number, id)
+			continue
+		}
+		links := 0
+		for column := range row.Values {
+			if strings.HasPrefix(column, "link") {
+				links += 1
+			}
+		}
+		if len(info.Links) != links {
+			t.Errorf("%s: Load() has %d links, LoadRows() has %d", id, len(info.Links), links)
+		}
+		for _, link := range info.Links {
+			if link.Url == "" {
+				t.Errorf("%s: link '%s' without URL", id, link.Name)
+			}
+		}
+	}
+}
+
+func TestCsvFileLoad(t *testing.T) {
+	tempDir := t.TempDir()
+	dataFile := filepath.Join(tempDir, "data.csv")
+	plannedFile := filepath.Join(tempDir, "planned.csv")
+
+	data := "id;name;city;state;status;first;coordinates;instagram;link1;cafe;cafe_coordinates\n" +
+		"dietenbach;Dietenbach parkrun;Freiburg;BW;;01.01.2020;48.0015924 7.8060492;https://www.instagram.com/dietenbachparkrun/;Homepage|https://example.com;Café Steinecke;48.001 7.807\n"
+	if err := os.WriteFile(dataFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	planned := "name,city,state,status,added,start\n" +
+		"Neuer Park parkrun,Musterstadt,BY,termin,2026-01-01,2026-06-06\n"
+	if err := os.WriteFile(plannedFile, []byte(planned), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, plannedData, err := CsvFile{Path: dataFile, PlannedPath: plannedFile}.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	info, found := infos["dietenbach"]
+	if !found {
+		t.Fatalf("missing info for 'dietenbach'")
+	}
+	if info.City != "Freiburg" || info.State != "BW" || info.Coordinates != "48.0015924 7.8060492" || !info.Updated.IsZero() {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.Cafe == nil || info.Cafe.Name != "Café Steinecke" || info.Cafe.Coordinates.Lat != 48.001 || info.Cafe.Coordinates.Lon != 7.807 {
+		t.Fatalf("unexpected cafe: %+v", info.Cafe)
+	}
+	if len(info.Links) != 2 || info.Links[0].Name != "Instagram" || info.Links[1].Name != "Homepage" {
+		t.Fatalf("unexpected links: %v", info.Links)
+	}
+
+	if len(plannedData) != 1 || plannedData[0].Status != "termin" || plannedData[0].Start.String() != "06.06.2026" {
+		t.Fatalf("unexpected planned data: %+v", plannedData)
+	}
+}
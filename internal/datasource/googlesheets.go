@@ -0,0 +1,79 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flopp/go-googlesheetswrapper"
+	"github.com/flopp/parkrun-map/internal/parkrun"
+)
+
+// GoogleSheets loads the data from the 'data' and 'planned' sheets of a Google Sheets document.
+type GoogleSheets struct {
+	ApiKey   string
+	SheetsId string
+}
+
+// readSheets returns the raw 'data' and 'planned' sheets (including the header rows).
+func (source GoogleSheets) readSheets() ([][]string, [][]string, error) {
+	ctx := context.Background()
+	client, err := googlesheetswrapper.New(source.ApiKey, source.SheetsId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating sheets client: %w", err)
+	}
+	allSheets, err := client.ReadAll(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading all sheets: %w", err)
+	}
+
+	sheet, found := allSheets["data"]
+	if !found {
+		return nil, nil, fmt.Errorf("sheet 'data' not found")
+	}
+	plannedSheet, found := allSheets["planned"]
+	if !found {
+		return nil, nil, fmt.Errorf("sheet 'planned' not found")
+	}
+
+	return sheet, plannedSheet, nil
+}
+
+func (source GoogleSheets) Load() (map[string]*parkrun.ParkrunInfo, []parkrun.PlannedData, error) {
+	sheet, plannedSheet, err := source.readSheets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// parse & validate columns
+	columns, err := googlesheetswrapper.ExtractHeader(sheet, dataColumns, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extracting header: %w", err)
+	}
+	addOptionalColumns(columns, sheet[0], optionalDataColumns)
+
+	parkrunInfos, err := parseDataRows(columns, sheet[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plannedColumnIndexes, err := googlesheetswrapper.ExtractHeader(plannedSheet, plannedColumns, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extracting header from planned sheet: %w", err)
+	}
+
+	plannedData, err := parsePlannedRows(plannedColumnIndexes, plannedSheet[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parkrunInfos, plannedData, nil
+}
+
+func (source GoogleSheets) LoadRows() (*Table, *Table, error) {
+	sheet, plannedSheet, err := source.readSheets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newTable("data", sheet), newTable("planned", plannedSheet), nil
+}
@@ -0,0 +1,291 @@
+package datasource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// JsonFile loads the data from a local JSON file in the format of data/parkruns.json.
+// The file either contains a list of events, or an object with "events" and "planned" lists.
+type JsonFile struct {
+	Path string
+}
+
+type jsonLink struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type jsonCafe struct {
+	Name        string `json:"name"`
+	GoogleMaps  string `json:"googlemaps"`
+	Coordinates string `json:"coordinates"`
+}
+
+type jsonEvent struct {
+	Id          string     `json:"id"`
+	Updated     string     `json:"updated"`
+	Name        string     `json:"name"`
+	City        string     `json:"city"`
+	State       string     `json:"state"`
+	Location    string     `json:"location"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Archived    string     `json:"archived"`
+	First       string     `json:"first"`
+	Coordinates string     `json:"coordinates"`
+	RouteType   string     `json:"routetype"`
+	RouteId     string     `json:"routeid"`
+	GoogleMaps  string     `json:"googlemaps"`
+	Cafe        *jsonCafe  `json:"cafe"`
+	Strava      []jsonLink `json:"strava"`
+	Social      []jsonLink `json:"social"`
+	Links       []jsonLink `json:"links"`
+}
+
+type jsonPlanned struct {
+	Name      string   `json:"name"`
+	City      string   `json:"city"`
+	State     string   `json:"state"`
+	Status    string   `json:"status"`
+	Added     string   `json:"added"`
+	Start     string   `json:"start"`
+	Instagram string   `json:"instagram"`
+	Link      jsonLink `json:"link"`
+}
+
+type jsonData struct {
+	Events  []jsonEvent   `json:"events"`
+	Planned []jsonPlanned `json:"planned"`
+}
+
+// cityState splits "Köln, NRW" into city "Köln" and state "NRW" if there's no explicit state.
+func (e jsonEvent) cityState() (string, string) {
+	if e.State == "" {
+		if city, state, found := strings.Cut(e.City, ", "); found {
+			return city, state
+		}
+	}
+	return e.City, e.State
+}
+
+func (e jsonEvent) info() (*parkrun.ParkrunInfo, error) {
+	var updated time.Time
+	if e.Updated != "" {
+		t, err := time.Parse("2006-01-02", e.Updated)
+		if err != nil {
+			return nil, fmt.Errorf("parsing updated date of '%s': %w", e.Id, err)
+		}
+		updated = t
+	}
+
+	status, err := parkrun.ParseStatus(e.Status)
+	if err != nil {
+		return nil, fmt.Errorf("parsing status of '%s': %w", e.Id, err)
+	}
+	var archived time.Time
+	if e.Archived != "" {
+		t, err := time.Parse("2006-01-02", e.Archived)
+		if err != nil {
+			return nil, fmt.Errorf("parsing archived date of '%s': %w", e.Id, err)
+		}
+		archived = t
+	}
+
+	city, state := e.cityState()
+
+	first := e.First
+	if first == "?" {
+		first = "-"
+	}
+
+	// links without URL are placeholders, just like empty cells in the sheet
+	links := make([]parkrun.Link, 0)
+	for _, link := range e.Social {
+		if link.Url != "" {
+			links = append(links, parkrun.Link{Name: link.Name, Url: link.Url})
+		}
+	}
+	for _, link := range e.Strava {
+		if link.Url != "" {
+			links = append(links, parkrun.Link{Name: "Strava " + link.Name, Url: link.Url})
+		}
+	}
+	for _, link := range e.Links {
+		if link.Url != "" {
+			links = append(links, parkrun.Link{Name: link.Name, Url: link.Url})
+		}
+	}
+
+	var cafe *parkrun.Cafe
+	if e.Cafe != nil && e.Cafe.Name != "" {
+		cafeCoordinates, err := utils.ParseCoordinates(e.Cafe.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("parsing cafe coordinates of '%s': %w", e.Id, err)
+		}
+		cafe = &parkrun.Cafe{Name: e.Cafe.Name, GoogleMaps: e.Cafe.GoogleMaps, Coordinates: cafeCoordinates}
+	}
+
+	return &parkrun.ParkrunInfo{
+		Id:          e.Id,
+		Name:        e.Name,
+		City:        city,
+		State:       state,
+		Location:    e.Location,
+		Description: e.Description,
+		RouteType:   e.RouteType,
+		RouteID:     e.RouteId,
+		GoogleMaps:  e.GoogleMaps,
+		Updated:     updated,
+		First:       first,
+		Status:      status,
+		Archived:    archived,
+		Coordinates: e.Coordinates,
+		Links:       links,
+		Cafe:        cafe,
+	}, nil
+}
+
+func (source JsonFile) read() (*jsonData, error) {
+	buf, err := utils.ReadFile(source.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &jsonData{}
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("[")) {
+		err = json.Unmarshal(buf, &data.Events)
+	} else {
+		err = json.Unmarshal(buf, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", source.Path, err)
+	}
+	return data, nil
+}
+
+func (source JsonFile) Load() (map[string]*parkrun.ParkrunInfo, []parkrun.PlannedData, error) {
+	data, err := source.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parkrunInfos := make(map[string]*parkrun.ParkrunInfo)
+	for _, e := range data.Events {
+		info, err := e.info()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", source.Path, err)
+		}
+		parkrunInfos[info.Id] = info
+	}
+
+	plannedData := make([]parkrun.PlannedData, 0, len(data.Planned))
+	for _, p := range data.Planned {
+		status, err := parkrun.ParsePlannedStatus(p.Status)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: planned parkrun '%s': %w", source.Path, p.Name, err)
+		}
+		added, err := parkrun.ParsePlannedDate(p.Added)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: added date of planned parkrun '%s': %w", source.Path, p.Name, err)
+		}
+		start, err := parkrun.ParsePlannedDate(p.Start)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: start date of planned parkrun '%s': %w", source.Path, p.Name, err)
+		}
+		plannedData = append(plannedData, parkrun.PlannedData{
+			Name:      p.Name,
+			City:      p.City,
+			State:     p.State,
+			Status:    status,
+			Added:     added,
+			Start:     start,
+			Instagram: p.Instagram,
+			Link1:     parkrun.Link{Name: p.Link.Name, Url: p.Link.Url},
+		})
+	}
+
+	return parkrunInfos, plannedData, nil
+}
+
+func (source JsonFile) LoadRows() (*Table, *Table, error) {
+	data, err := source.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// like hand-written CSV files, JSON files may omit the 'updated' dates completely
+	hasUpdated := false
+	for _, e := range data.Events {
+		hasUpdated = hasUpdated || e.Updated != ""
+	}
+	dataTable := &Table{Name: "data"}
+	for _, column := range append(append([]string{}, dataColumns...), optionalDataColumns...) {
+		if column != "updated" || hasUpdated {
+			dataTable.Columns = append(dataTable.Columns, column)
+		}
+	}
+	for i, e := range data.Events {
+		city, state := e.cityState()
+		values := map[string]string{
+			"id":              e.Id,
+			"updated":         e.Updated,
+			"name":            e.Name,
+			"city":            city,
+			"state":           state,
+			"location":        e.Location,
+			"description":     e.Description,
+			"status":          e.Status,
+			"archived":        e.Archived,
+			"first":           e.First,
+			"coordinates":     e.Coordinates,
+			"route_type":      e.RouteType,
+			"google_route_id": e.RouteId,
+			"google_maps_url": e.GoogleMaps,
+		}
+		if e.Cafe != nil {
+			values["cafe"] = e.Cafe.Name
+			values["cafe_google_maps_url"] = e.Cafe.GoogleMaps
+			values["cafe_coordinates"] = e.Cafe.Coordinates
+		}
+		links := make([]jsonLink, 0)
+		links = append(links, e.Social...)
+		links = append(links, e.Strava...)
+		links = append(links, e.Links...)
+		j := 0
+		for _, link := range links {
+			// links without URL are placeholders, just like empty cells in the sheet
+			if link.Url == "" {
+				continue
+			}
+			j += 1
+			values[fmt.Sprintf("link%d", j)] = fmt.Sprintf("%s|%s", link.Name, link.Url)
+		}
+		dataTable.Rows = append(dataTable.Rows, Row{Number: i + 1, Values: values})
+	}
+
+	plannedTable := &Table{Name: "planned", Columns: plannedColumns}
+	for i, p := range data.Planned {
+		values := map[string]string{
+			"name":      p.Name,
+			"city":      p.City,
+			"state":     p.State,
+			"status":    p.Status,
+			"added":     p.Added,
+			"start":     p.Start,
+			"instagram": p.Instagram,
+		}
+		if p.Link.Name != "" || p.Link.Url != "" {
+			values["link1"] = fmt.Sprintf("%s|%s", p.Link.Name, p.Link.Url)
+		}
+		plannedTable.Rows = append(plannedTable.Rows, Row{Number: i + 1, Values: values})
+	}
+
+	return dataTable, plannedTable, nil
+}
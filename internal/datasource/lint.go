@@ -0,0 +1,252 @@
+package datasource
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/parkrun"
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is a single finding of Lint.
+type Problem struct {
+	Table    string
+	Row      int // 0 if the problem concerns the whole table
+	Id       string
+	Severity string
+	Message  string
+}
+
+func (p Problem) String() string {
+	location := p.Table
+	if p.Row > 0 {
+		location = fmt.Sprintf("%s:%d", p.Table, p.Row)
+	}
+	if p.Id != "" {
+		location = fmt.Sprintf("%s (%s)", location, p.Id)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Severity, p.Message)
+}
+
+// HasErrors returns true if at least one of the problems is an error (and not just a warning).
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// route types are free text, but usually describe the laps or an out-and-back course
+var reRouteType = regexp.MustCompile(`(?i)(runde|hin und zurück|hin- und rück|wendepunkt|punkt zu punkt)`)
+
+// a rough bounding box of Germany; coordinates outside are suspicious but not wrong per se
+var germanyMin = utils.Coordinates{Lat: 47.2, Lon: 5.8}
+var germanyMax = utils.Coordinates{Lat: 55.1, Lon: 15.1}
+
+type linter struct {
+	table    string
+	problems []Problem
+}
+
+func (l *linter) add(row Row, id string, severity string, format string, args ...any) {
+	l.problems = append(l.problems, Problem{Table: l.table, Row: row.Number, Id: id, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) checkColumns(table *Table, required []string) {
+	for _, name := range required {
+		if !table.HasColumn(name) {
+			l.add(Row{}, "", SeverityError, "missing column '%s'", name)
+		}
+	}
+}
+
+func (l *linter) checkLink(row Row, id string, column string) {
+	value := row.Get(column)
+	if value == "" {
+		return
+	}
+	link, err := parkrun.ParseLink(value)
+	if err != nil {
+		l.add(row, id, SeverityError, "malformed link in column '%s': expected 'NAME|URL', got '%s'", column, value)
+	} else if !link.IsValid() {
+		l.add(row, id, SeverityError, "incomplete link in column '%s': '%s'", column, value)
+	} else if !strings.HasPrefix(link.Url, "https://") && !strings.HasPrefix(link.Url, "http://") {
+		l.add(row, id, SeverityWarning, "link in column '%s' is not an http(s) URL: '%s'", column, link.Url)
+	}
+}
+
+// checkDate reports values of the column that match none of the layouts (and none of the allowed placeholders).
+func (l *linter) checkDate(row Row, id string, column string, layouts []string, allowed ...string) {
+	value := row.Get(column)
+	if contains(allowed, value) {
+		return
+	}
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+	l.add(row, id, SeverityError, "invalid date in column '%s': expected format %s, got '%s'", column, strings.Join(layouts, " or "), value)
+}
+
+func (l *linter) checkCoordinates(row Row, id string, column string, required bool) {
+	value := row.Get(column)
+	if value == "" {
+		if required {
+			l.add(row, id, SeverityError, "missing coordinates in column '%s'", column)
+		}
+		return
+	}
+	c, err := utils.ParseCoordinates(value)
+	if err != nil {
+		l.add(row, id, SeverityError, "malformed coordinates in column '%s': '%s'", column, value)
+		return
+	}
+	if c.Lat < -90 || c.Lat > 90 || c.Lon < -180 || c.Lon > 180 {
+		l.add(row, id, SeverityError, "coordinates out of range in column '%s': '%s'", column, value)
+		return
+	}
+	if c.Lat < germanyMin.Lat || c.Lat > germanyMax.Lat || c.Lon < germanyMin.Lon || c.Lon > germanyMax.Lon {
+		l.add(row, id, SeverityWarning, "coordinates outside of Germany in column '%s' (swapped lat/lon?): '%s'", column, value)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks all rows of the raw 'data' and 'planned' tables and reports all problems at once.
+// eventIds contains the ids of the German events from events.json; if it is nil, the corresponding check is skipped.
+func Lint(data *Table, planned *Table, eventIds map[string]struct{}) []Problem {
+	problems := make([]Problem, 0)
+	problems = append(problems, lintData(data, eventIds)...)
+	problems = append(problems, lintPlanned(planned)...)
+	return problems
+}
+
+func lintData(data *Table, eventIds map[string]struct{}) []Problem {
+	l := &linter{table: data.Name}
+	l.checkColumns(data, []string{"id", "name", "city", "state", "status", "first", "coordinates"})
+
+	firstRow := make(map[string]int)
+	for _, row := range data.Rows {
+		id := row.Get("id")
+		if id == "" {
+			l.add(row, id, SeverityError, "missing id")
+		} else if first, found := firstRow[id]; found {
+			l.add(row, id, SeverityError, "duplicate id, first defined in row %d", first)
+		} else {
+			firstRow[id] = row.Number
+		}
+
+		status, err := parkrun.ParseStatus(row.Get("status"))
+		if err != nil {
+			l.add(row, id, SeverityError, "%v", err)
+		}
+		archived := status == parkrun.StatusArchived
+		if row.Get("archived") != "" {
+			l.checkDate(row, id, "archived", []string{"2006-01-02"})
+			if !archived {
+				l.add(row, id, SeverityWarning, "archived date, but status is '%s'", status.Label())
+			}
+		}
+
+		if id != "" && eventIds != nil && status != parkrun.StatusPlanned && !archived {
+			if _, found := eventIds[id]; !found {
+				l.add(row, id, SeverityError, "id not found in events.json")
+			}
+		}
+
+		if data.HasColumn("updated") {
+			l.checkDate(row, id, "updated", []string{"2006-01-02"})
+		}
+		l.checkDate(row, id, "first", []string{"02.01.2006"}, "", "-", "?")
+
+		if row.Get("name") == "" {
+			l.add(row, id, SeverityError, "missing name")
+		}
+		if row.Get("state") == "" {
+			if archived {
+				l.add(row, id, SeverityWarning, "missing state")
+			} else {
+				l.add(row, id, SeverityError, "missing state")
+			}
+		}
+
+		// events that are not (yet) in events.json need explicit coordinates
+		_, inEventsJson := eventIds[id]
+		l.checkCoordinates(row, id, "coordinates", eventIds != nil && !inEventsJson && !archived)
+
+		if routeType := row.Get("route_type"); routeType != "" && !reRouteType.MatchString(routeType) {
+			l.add(row, id, SeverityWarning, "unknown route type '%s'", routeType)
+		}
+
+		for j := 1; j <= 5; j++ {
+			l.checkLink(row, id, fmt.Sprintf("link%d", j))
+		}
+
+		if cafe := row.Get("cafe"); cafe != "" {
+			if row.Get("cafe_coordinates") == "" {
+				l.add(row, id, SeverityWarning, "cafe without coordinates, it won't be shown on the map")
+			}
+			l.checkCoordinates(row, id, "cafe_coordinates", false)
+		} else if row.Get("cafe_coordinates") != "" || row.Get("cafe_google_maps_url") != "" {
+			l.add(row, id, SeverityWarning, "cafe details without cafe name")
+		}
+	}
+
+	return l.problems
+}
+
+func lintPlanned(planned *Table) []Problem {
+	l := &linter{table: planned.Name}
+	if len(planned.Columns) == 0 && len(planned.Rows) == 0 {
+		// no planned table at all (e.g. CSV source without planned file)
+		return l.problems
+	}
+	l.checkColumns(planned, []string{"name", "status"})
+
+	for _, row := range planned.Rows {
+		name := row.Get("name")
+		if name == "" {
+			l.add(row, name, SeverityError, "missing name")
+		}
+		if _, err := parkrun.ParsePlannedStatus(row.Get("status")); err != nil {
+			l.add(row, name, SeverityError, "%v", err)
+		}
+		// planned dates are often only known roughly
+		for _, column := range []string{"added", "start"} {
+			if _, err := parkrun.ParsePlannedDate(row.Get(column)); err != nil {
+				l.add(row, name, SeverityError, "invalid date in column '%s': %v", column, err)
+			}
+		}
+		l.checkLink(row, name, "link1")
+	}
+
+	return l.problems
+}
+
+// SortProblems sorts the problems by table and row number.
+func SortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Table != problems[j].Table {
+			return problems[i].Table < problems[j].Table
+		}
+		return problems[i].Row < problems[j].Row
+	})
+}
@@ -0,0 +1,70 @@
+package datasource
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	data := newTable("data", [][]string{
+		{"id", "updated", "name", "city", "state", "status", "first", "coordinates", "route_type", "link1"},
+		{"dietenbach", "2024-05-01", "Dietenbach parkrun", "Freiburg", "BW", "", "01.01.2020", "48.0015924 7.8060492", "2 Runden", "Homepage|https://example.com"},
+		{"dietenbach", "01.05.2024", "Dietenbach parkrun", "Freiburg", "", "aktiv", "2020-01-01", "7.8060492 48.0015924", "Rundkurs", "https://example.com"},
+		{"unknown", "2024-05-01", "Unknown parkrun", "Irgendwo", "BY", "", "-", "", "", ""},
+		{"neu", "2024-05-01", "Neu parkrun", "Neustadt", "BY", "geplant", "?", "91 10", "", ""},
+		{"alt", "2024-05-01", "Alt parkrun", "Altstadt", "", "archiviert", "", "", "", ""},
+	})
+	planned := newTable("planned", [][]string{
+		{"name", "city", "state", "status", "added", "start", "instagram", "link1"},
+		{"Neuer Park parkrun", "Musterstadt", "BY", "termin", "2026-01-01", "06.2026", "", ""},
+		{"", "Musterstadt", "BY", "bald", "gestern", "", "", "Instagram|"},
+	})
+	eventIds := map[string]struct{}{"dietenbach": {}}
+
+	problems := Lint(data, planned, eventIds)
+
+	expected := []string{
+		"data:3 (dietenbach): error: duplicate id, first defined in row 2",
+		"data:3 (dietenbach): error: unknown status 'aktiv'",
+		"data:3 (dietenbach): error: invalid date in column 'updated': expected format 2006-01-02, got '01.05.2024'",
+		"data:3 (dietenbach): error: invalid date in column 'first': expected format 02.01.2006, got '2020-01-01'",
+		"data:3 (dietenbach): error: missing state",
+		"data:3 (dietenbach): warning: coordinates outside of Germany in column 'coordinates' (swapped lat/lon?): '7.8060492 48.0015924'",
+		"data:3 (dietenbach): warning: unknown route type 'Rundkurs'",
+		"data:3 (dietenbach): error: malformed link in column 'link1': expected 'NAME|URL', got 'https://example.com'",
+		"data:4 (unknown): error: id not found in events.json",
+		"data:4 (unknown): error: missing coordinates in column 'coordinates'",
+		"data:5 (neu): error: coordinates out of range in column 'coordinates': '91 10'",
+		"data:6 (alt): warning: missing state",
+		"planned:3: error: missing name",
+		"planned:3: error: unknown planned status 'bald'",
+		"planned:3: error: invalid date in column 'added': cannot parse date 'gestern'",
+		"planned:3: error: incomplete link in column 'link1': 'Instagram|'",
+	}
+	actual := make([]string, 0, len(problems))
+	for _, p := range problems {
+		actual = append(actual, p.String())
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected problems:\n%s\nexpected:\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+	if !HasErrors(problems) {
+		t.Fatalf("expected errors")
+	}
+}
+
+func TestLintJsonFile(t *testing.T) {
+	data, planned, err := JsonFile{Path: "../../data/parkruns.json"}.LoadRows()
+	if err != nil {
+		t.Fatalf("LoadRows() error = %v", err)
+	}
+	if len(data.Rows) == 0 {
+		t.Fatalf("expected rows")
+	}
+
+	for _, p := range Lint(data, planned, nil) {
+		if p.Severity == SeverityError {
+			t.Errorf("unexpected error: %s", p)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package datasource
+
+import (
+	"strings"
+)
+
+// Row is a single raw row of the 'data' or 'planned' table.
+type Row struct {
+	Number int // row number in the sheet or file (the header is row 1), or position in a JSON list (starting at 1)
+	Values map[string]string
+}
+
+func (row Row) Get(name string) string {
+	return strings.TrimSpace(row.Values[name])
+}
+
+// Table holds the raw, unvalidated rows of the 'data' or 'planned' table, e.g. for linting.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    []Row
+}
+
+func (table *Table) HasColumn(name string) bool {
+	for _, column := range table.Columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newTable creates a table from a sheet whose first row contains the column names.
+func newTable(name string, sheet [][]string) *Table {
+	table := &Table{Name: name}
+	if len(sheet) == 0 {
+		return table
+	}
+
+	for _, column := range sheet[0] {
+		table.Columns = append(table.Columns, strings.ToLower(strings.TrimSpace(column)))
+	}
+	for i, values := range sheet[1:] {
+		row := Row{Number: i + 2, Values: make(map[string]string)}
+		for j, value := range values {
+			if j < len(table.Columns) && table.Columns[j] != "" {
+				row.Values[table.Columns[j]] = value
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
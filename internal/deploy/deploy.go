@@ -0,0 +1,57 @@
+package deploy
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Target receives the files of the output directory.
+type Target interface {
+	// Deploy uploads and deletes the files of the plan; srcDir is the output directory.
+	Deploy(srcDir string, plan Plan) error
+	// String describes the target for the deploy plan.
+	String() string
+}
+
+// Plan lists the files to upload and to delete (slash separated paths relative to the output directory).
+type Plan struct {
+	Upload []string
+	Delete []string
+}
+
+// NewPlan compares the files of the current build with the files of the previous deployment (path -> content hash):
+// new and changed files are uploaded, files that are no longer part of the build are deleted.
+func NewPlan(deployed map[string]string, current map[string]string) Plan {
+	plan := Plan{Upload: make([]string, 0), Delete: make([]string, 0)}
+	for path, hash := range current {
+		if deployedHash, found := deployed[path]; !found || deployedHash != hash {
+			plan.Upload = append(plan.Upload, path)
+		}
+	}
+	for path := range deployed {
+		if _, found := current[path]; !found {
+			plan.Delete = append(plan.Delete, path)
+		}
+	}
+	sort.Strings(plan.Upload)
+	sort.Strings(plan.Delete)
+	return plan
+}
+
+// Empty reports whether there's nothing to deploy.
+func (plan Plan) Empty() bool {
+	return len(plan.Upload) == 0 && len(plan.Delete) == 0
+}
+
+// Write prints the plan.
+func (plan Plan) Write(w io.Writer, target Target) {
+	fmt.Fprintf(w, "deploying to %s\n", target)
+	for _, path := range plan.Upload {
+		fmt.Fprintf(w, "  upload  %s\n", path)
+	}
+	for _, path := range plan.Delete {
+		fmt.Fprintf(w, "  delete  %s\n", path)
+	}
+	fmt.Fprintf(w, "%d files to upload, %d files to delete\n", len(plan.Upload), len(plan.Delete))
+}
@@ -0,0 +1,47 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// LocalDir deploys to a directory of the local file system (e.g. a mounted web root).
+type LocalDir struct {
+	Dir string
+}
+
+func (t LocalDir) String() string {
+	return fmt.Sprintf("local directory %s", t.Dir)
+}
+
+func (t LocalDir) Deploy(srcDir string, plan Plan) error {
+	for _, path := range plan.Upload {
+		src := filepath.Join(srcDir, filepath.FromSlash(path))
+		dst := filepath.Join(t.Dir, filepath.FromSlash(path))
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFile(dst, content); err != nil {
+			return fmt.Errorf("while writing %s: %w", dst, err)
+		}
+		// keep the modification time like "rsync -a"
+		if info, err := os.Stat(src); err == nil {
+			if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range plan.Delete {
+		dst := filepath.Join(t.Dir, filepath.FromSlash(path))
+		if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("while deleting %s: %w", dst, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// Rsync deploys to a directory of a remote host via rsync over SSH.
+type Rsync struct {
+	Host  string // SSH host (e.g. "user@example.com")
+	Dir   string // directory on the host
+	Chmod string // optional mode applied to the directory after uploading (e.g. "o=u", with "chmod -R")
+
+	// run executes the commands (replaced by tests)
+	run func(cmd *exec.Cmd) error
+}
+
+func (t Rsync) String() string {
+	return fmt.Sprintf("rsync %s:%s", t.Host, t.Dir)
+}
+
+func (t Rsync) exec(stdin string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if t.run != nil {
+		return t.run(cmd)
+	}
+	return cmd.Run()
+}
+
+// shellQuote quotes s for the remote shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (t Rsync) Deploy(srcDir string, plan Plan) error {
+	if t.Host == "" || t.Dir == "" {
+		return fmt.Errorf("rsync target needs a host and a directory")
+	}
+	if len(plan.Upload) > 0 {
+		// only transfer the files of the plan
+		files := strings.Join(plan.Upload, "\n") + "\n"
+		if err := t.exec(files, "rsync", "-a", "--files-from=-", strings.TrimSuffix(srcDir, "/")+"/", t.Host+":"+strings.TrimSuffix(t.Dir, "/")+"/"); err != nil {
+			return fmt.Errorf("while running rsync: %w", err)
+		}
+	}
+	if len(plan.Delete) > 0 {
+		args := []string{"rm", "-f", "--"}
+		for _, p := range plan.Delete {
+			args = append(args, shellQuote(path.Join(t.Dir, p)))
+		}
+		if err := t.exec("", "ssh", t.Host, strings.Join(args, " ")); err != nil {
+			return fmt.Errorf("while deleting files: %w", err)
+		}
+	}
+	if t.Chmod != "" {
+		if err := t.exec("", "ssh", t.Host, strings.Join([]string{"chmod", "-R", shellQuote(t.Chmod), shellQuote(t.Dir)}, " ")); err != nil {
+			return fmt.Errorf("while changing file modes: %w", err)
+		}
+	}
+	return nil
+}
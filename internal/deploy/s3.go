@@ -0,0 +1,165 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 deploys to a bucket of an S3-compatible object storage (AWS S3, MinIO, ...) using path-style URLs.
+type S3 struct {
+	Endpoint  string // e.g. "https://s3.eu-central-1.amazonaws.com" or "http://localhost:9000"
+	Region    string // default: "us-east-1"
+	Bucket    string
+	Prefix    string // optional key prefix
+	AccessKey string
+	SecretKey string
+
+	Client *http.Client // default: http.DefaultClient
+	now    func() time.Time
+}
+
+func (t S3) String() string {
+	return fmt.Sprintf("s3 %s/%s/%s", strings.TrimSuffix(t.Endpoint, "/"), t.Bucket, t.Prefix)
+}
+
+func (t S3) region() string {
+	if t.Region == "" {
+		return "us-east-1"
+	}
+	return t.Region
+}
+
+// s3Escape URI-encodes the key as required by the signature (everything except unreserved characters and '/').
+func s3Escape(key string) string {
+	var sb strings.Builder
+	for _, b := range []byte(key) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') || strings.IndexByte("-._~/", b) >= 0 {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+// contentType returns the content type of the file (calendars are served as text/calendar like with the .htaccess).
+func contentType(filePath string) string {
+	ext := filepath.Ext(filePath)
+	if ext == ".ics" {
+		return "text/calendar"
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sign adds an AWS signature version 4 to the request; all headers set so far are signed.
+func (t S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, t.region())
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+t.SecretKey), date)
+	key = hmacSHA256(key, t.region())
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s", t.AccessKey, scope, signedHeaders, signature))
+}
+
+func (t S3) request(method string, key string, body []byte, headers map[string]string) error {
+	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(t.Endpoint, "/"), t.Bucket, s3Escape(path.Join(t.Prefix, key)))
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	t.sign(req, sha256Hex(body), now())
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, url, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (t S3) Deploy(srcDir string, plan Plan) error {
+	if t.Endpoint == "" || t.Bucket == "" {
+		return fmt.Errorf("s3 target needs an endpoint and a bucket")
+	}
+	for _, p := range plan.Upload {
+		content, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		if err := t.request(http.MethodPut, p, content, map[string]string{"Content-Type": contentType(p)}); err != nil {
+			return fmt.Errorf("while uploading %s: %w", p, err)
+		}
+	}
+	for _, p := range plan.Delete {
+		if err := t.request(http.MethodDelete, p, nil, nil); err != nil {
+			return fmt.Errorf("while deleting %s: %w", p, err)
+		}
+	}
+	return nil
+}
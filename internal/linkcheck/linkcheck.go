@@ -0,0 +1,259 @@
+package linkcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// Status classifies the result of a link check.
+type Status string
+
+const (
+	StatusOk       Status = "ok"
+	StatusRedirect Status = "redirect" // reachable, but moved to another URL
+	StatusBroken   Status = "broken"   // error status (e.g. 404, 410, 500)
+	StatusSoft404  Status = "soft-404" // reachable, but the page says "not found" (or redirects to the start page)
+	StatusBlocked  Status = "blocked"  // the site refuses bots (e.g. 403, 429, captcha, login); the link itself may be fine
+	StatusError    Status = "error"    // network error (DNS, TLS, timeout, ...)
+)
+
+// Result is the result of checking a single URL.
+type Result struct {
+	Url      string    `json:"url"`
+	Status   Status    `json:"status"`
+	Code     int       `json:"code,omitempty"`    // HTTP status code of the final response
+	FinalUrl string    `json:"final,omitempty"`   // target of redirects
+	Message  string    `json:"message,omitempty"` // details (e.g. the network error)
+	Checked  time.Time `json:"checked"`
+}
+
+// Checker checks URLs concurrently with a limit per host; results are cached in a file.
+type Checker struct {
+	Client      *http.Client  // default: a client with a 20s timeout
+	Concurrency int           // max. number of concurrent requests (default: 8)
+	PerHost     int           // max. number of concurrent requests per host (default: 2)
+	CacheFile   string        // optional
+	MaxAge      time.Duration // max. age of cached results
+	UserAgent   string
+
+	now func() time.Time
+}
+
+// a browser user agent; some sites refuse unknown clients
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/104.0.0.0 Safari/537.36"
+
+// phrases of "not found" pages that are delivered with status 200
+var reSoft404 = regexp.MustCompile(`(?i)(page not found|seite nicht gefunden|sorry, this page isn't available|diese seite ist leider nicht verfügbar|this content isn't available|page isn't available)`)
+
+// loadCache reads the cached results; a missing or broken cache file is ignored.
+func (c *Checker) loadCache() map[string]Result {
+	cache := make(map[string]Result)
+	if c.CacheFile == "" {
+		return cache
+	}
+	buf, err := os.ReadFile(c.CacheFile)
+	if err != nil {
+		return cache
+	}
+	_ = json.Unmarshal(buf, &cache)
+	return cache
+}
+
+func (c *Checker) saveCache(cache map[string]Result) error {
+	if c.CacheFile == "" {
+		return nil
+	}
+	buf, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(c.CacheFile, buf)
+}
+
+func (c *Checker) time() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Check checks all URLs and returns the results by URL; cached results that are younger than MaxAge are reused.
+// Network errors are not cached.
+func (c *Checker) Check(urls []string) (map[string]Result, error) {
+	cache := c.loadCache()
+	now := c.time()
+	results := make(map[string]Result)
+
+	todo := make([]string, 0)
+	for _, u := range urls {
+		if _, found := results[u]; found {
+			continue
+		}
+		if cached, found := cache[u]; found && now.Sub(cached.Checked) < c.MaxAge {
+			results[u] = cached
+			continue
+		}
+		results[u] = Result{}
+		todo = append(todo, u)
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	perHost := c.PerHost
+	if perHost <= 0 {
+		perHost = 2
+	}
+	var mu sync.Mutex
+	hostSlots := make(map[string]chan struct{})
+	hostSlot := func(host string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		slot, found := hostSlots[host]
+		if !found {
+			slot = make(chan struct{}, perHost)
+			hostSlots[host] = slot
+		}
+		return slot
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for _, u := range todo {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			host := ""
+			if parsed, err := url.Parse(u); err == nil {
+				host = strings.ToLower(parsed.Hostname())
+			}
+			hs := hostSlot(host)
+			hs <- struct{}{}
+			slots <- struct{}{}
+			result := c.checkUrl(u)
+			<-slots
+			<-hs
+
+			mu.Lock()
+			results[u] = result
+			if result.Status != StatusError {
+				cache[u] = result
+			}
+			mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+
+	return results, c.saveCache(cache)
+}
+
+// response is the relevant part of an HTTP response.
+type response struct {
+	code     int
+	finalUrl string
+	header   http.Header
+	body     string
+}
+
+func (c *Checker) request(u string) (*response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 256*1024))
+	return &response{code: res.StatusCode, finalUrl: res.Request.URL.String(), header: res.Header, body: string(body)}, nil
+}
+
+// checkUrl requests the URL and classifies the response. It always uses GET: "not found" pages are often delivered
+// with status 200, so the body is needed (HEAD responses have none), and many servers don't allow HEAD or answer it
+// differently.
+func (c *Checker) checkUrl(u string) Result {
+	result := Result{Url: u, Checked: c.time()}
+	res, err := c.request(u)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		result.Status = StatusError
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Code = res.code
+	if res.finalUrl != u {
+		result.FinalUrl = res.finalUrl
+	}
+	result.Status, result.Message = classify(u, res)
+	return result
+}
+
+// classify determines the status of the final response of the URL.
+func classify(u string, res *response) (Status, string) {
+	switch {
+	case res.code == http.StatusForbidden || res.code == http.StatusTooManyRequests || res.code == 999:
+		return StatusBlocked, fmt.Sprintf("status %d", res.code)
+	case res.header.Get("cf-mitigated") == "challenge":
+		return StatusBlocked, "captcha challenge"
+	case res.code >= 400:
+		return StatusBroken, fmt.Sprintf("status %d", res.code)
+	case res.code >= 300:
+		// unfollowed redirect (e.g. too many redirects)
+		return StatusBroken, fmt.Sprintf("status %d", res.code)
+	}
+
+	if reSoft404.MatchString(res.body) {
+		return StatusSoft404, "page content says 'not found'"
+	}
+	if res.finalUrl != u && !sameUrl(u, res.finalUrl) {
+		original, err1 := url.Parse(u)
+		final, err2 := url.Parse(res.finalUrl)
+		if err1 == nil && err2 == nil {
+			// a specific page that redirects to the start page probably doesn't exist anymore
+			if strings.Trim(original.Path, "/") != "" && strings.Trim(final.Path, "/") == "" {
+				return StatusSoft404, "redirected to start page"
+			}
+			// sites like Instagram redirect bots to their login page
+			if strings.Contains(strings.ToLower(final.Path), "login") && !strings.Contains(strings.ToLower(original.Path), "login") {
+				return StatusBlocked, "redirected to login page"
+			}
+		}
+		return StatusRedirect, "moved to " + res.finalUrl
+	}
+	return StatusOk, ""
+}
+
+// sameUrl ignores differences in trailing slashes, "http" vs. "https" and "www.".
+func sameUrl(a, b string) bool {
+	normalize := func(s string) string {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "https://"), "http://")
+		return strings.TrimSuffix(strings.TrimPrefix(s, "www."), "/")
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,131 @@
+package linkcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testServer(requests *int32, active *int32, maxActive *int32) *httptest.Server {
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		mu.Lock()
+		*active += 1
+		if *active > *maxActive {
+			*maxActive = *active
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			*active -= 1
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		// the checker only sends GET requests (the body is needed to detect soft 404 pages)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/", "/ok", "/new-place", "/login":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/new-place", http.StatusMovedPermanently)
+		case "/profile":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/removed":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/blocked":
+			w.WriteHeader(http.StatusForbidden)
+		case "/soft", "/soft-gone":
+			// status 200, only the body says "not found"
+			_, _ = w.Write([]byte("<html><body><h1>Sorry, this page isn't available.</h1></body></html>"))
+		case "/soft-moved":
+			http.Redirect(w, r, "/soft-gone", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCheck(t *testing.T) {
+	var requests, active, maxActive int32
+	server := testServer(&requests, &active, &maxActive)
+	defer server.Close()
+
+	testCases := []struct {
+		path       string
+		wantStatus Status
+		wantCode   int
+		wantFinal  string
+	}{
+		{"/ok", StatusOk, 200, ""},
+		{"/moved", StatusRedirect, 200, "/new-place"},
+		{"/profile", StatusBlocked, 200, "/login"},
+		{"/removed", StatusSoft404, 200, "/"},
+		{"/gone", StatusBroken, 404, ""},
+		{"/blocked", StatusBlocked, 403, ""},
+		{"/soft", StatusSoft404, 200, ""},
+		{"/soft-moved", StatusSoft404, 200, "/soft-gone"},
+	}
+	urls := make([]string, 0, len(testCases))
+	for _, tc := range testCases {
+		urls = append(urls, server.URL+tc.path)
+	}
+	urls = append(urls, "http://127.0.0.1:1/unreachable")
+
+	checker := &Checker{CacheFile: filepath.Join(t.TempDir(), "links.json"), MaxAge: time.Hour, PerHost: 2, Concurrency: 8}
+	results, err := checker.Check(urls)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.path, func(t *testing.T) {
+			result := results[server.URL+tc.path]
+			wantFinal := ""
+			if tc.wantFinal != "" {
+				wantFinal = server.URL + tc.wantFinal
+			}
+			if result.Status != tc.wantStatus || result.Code != tc.wantCode || result.FinalUrl != wantFinal {
+				t.Fatalf("Check(%s) = %+v, want status %s, code %d, final %q", tc.path, result, tc.wantStatus, tc.wantCode, wantFinal)
+			}
+		})
+	}
+	if result := results["http://127.0.0.1:1/unreachable"]; result.Status != StatusError || result.Message == "" {
+		t.Fatalf("unreachable: %+v", result)
+	}
+	if maxActive > 2 {
+		t.Fatalf("%d concurrent requests to one host, want at most 2", maxActive)
+	}
+
+	// cached results are reused, network errors are checked again
+	before := atomic.LoadInt32(&requests)
+	results, err = checker.Check(urls)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if after := atomic.LoadInt32(&requests); after != before {
+		t.Fatalf("%d requests for cached results", after-before)
+	}
+	if results[server.URL+"/moved"].Status != StatusRedirect {
+		t.Fatalf("cached result = %+v", results[server.URL+"/moved"])
+	}
+
+	// expired results are checked again
+	checker.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := checker.Check([]string{server.URL + "/ok"}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if after := atomic.LoadInt32(&requests); after == before {
+		t.Fatalf("expired result has not been checked again")
+	}
+}
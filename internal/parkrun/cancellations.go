@@ -5,27 +5,62 @@ import (
 	"html"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
-type Cancellation struct {
-	Date        time.Time
-	Description string
+// CancellationReason is the (english) reason of a cancellation as listed in the parkrun wiki.
+type CancellationReason string
+
+const (
+	ReasonAedUnavailable          CancellationReason = "AED unavailable"
+	ReasonAirQuality              CancellationReason = "Air quality"
+	ReasonCourseUnsafe            CancellationReason = "Course unsafe or blocked"
+	ReasonEquipmentUnavailable    CancellationReason = "Equipment unavailable"
+	ReasonIncidentAfterStart      CancellationReason = "Incident after start"
+	ReasonLocalHealthRestrictions CancellationReason = "Local health restrictions"
+	ReasonNoParticipants          CancellationReason = "No participants"
+	ReasonPermissionIssue         CancellationReason = "Permission issue"
+	ReasonShortageOfVolunteers    CancellationReason = "Shortage of volunteers"
+	ReasonVenueUnavailable        CancellationReason = "Venue unavailable"
+	ReasonWeather                 CancellationReason = "Weather"
+	ReasonOther                   CancellationReason = ""
+)
+
+var cancellationReasonGerman = map[CancellationReason]string{
+	ReasonAedUnavailable:          "Defibrillator nicht verfügbar",
+	ReasonAirQuality:              "Luftqualität",
+	ReasonCourseUnsafe:            "Strecke unsicher oder gesperrt",
+	ReasonEquipmentUnavailable:    "Ausrüstung nicht verfügbar",
+	ReasonIncidentAfterStart:      "Vorfall nach dem Start",
+	ReasonLocalHealthRestrictions: "Örtliche Gesundheitsschutzmaßnahmen",
+	ReasonNoParticipants:          "Keine Teilnehmer*innen",
+	ReasonPermissionIssue:         "Genehmigungsproblem",
+	ReasonShortageOfVolunteers:    "Zu wenig Helfer*innen",
+	ReasonVenueUnavailable:        "Standort nicht verfügbar",
+	ReasonWeather:                 "Wetter",
+}
+
+// ParseCancellationReason maps the description from the wiki to a known reason (ReasonOther if unknown).
+func ParseCancellationReason(description string) CancellationReason {
+	reason := CancellationReason(strings.TrimSpace(description))
+	if _, found := cancellationReasonGerman[reason]; found {
+		return reason
+	}
+	return ReasonOther
 }
 
-var cancellationReasonGerman = map[string]string{
-	"AED unavailable":           "Defibrillator nicht verfügbar",
-	"Air quality":               "Luftqualität",
-	"Course unsafe or blocked":  "Strecke unsicher oder gesperrt",
-	"Equipment unavailable":     "Ausrüstung nicht verfügbar",
-	"Incident after start":      "Vorfall nach dem Start",
-	"Local health restrictions": "Örtliche Gesundheitsschutzmaßnahmen",
-	"No participants":           "Keine Teilnehmer*innen",
-	"Permission issue":          "Genehmigungsproblem",
-	"Shortage of volunteers":    "Zu wenig Helfer*innen",
-	"Venue unavailable":         "Standort nicht verfügbar",
-	"Weather":                   "Wetter",
+// German is the German translation of the reason ("" for ReasonOther).
+func (reason CancellationReason) German() string {
+	return cancellationReasonGerman[reason]
+}
+
+type Cancellation struct {
+	Date        time.Time
+	EventName   string // the event name as listed in the wiki
+	Reason      CancellationReason
+	Description string // the raw description from the wiki
 }
 
 func (c Cancellation) DateF() string {
@@ -34,21 +69,28 @@ func (c Cancellation) DateF() string {
 
 // ReasonGerman maps the cancellation description from the wiki (english) to German.
 func (c Cancellation) ReasonGerman() string {
-	if reason, found := cancellationReasonGerman[c.Description]; found {
+	if reason := c.Reason.German(); reason != "" {
 		return reason
 	}
 	return c.Description
 }
 
-// ParseCancellationsWiki returns a map of event name to list of cancellations for that event, parsed from the given wiki file.
-func ParseCancellationsWiki(cancellationsFilePath string) (map[string][]Cancellation, error) {
+// Upcoming returns true if the cancellation is on the day of 'now' or later.
+func (c Cancellation) Upcoming(now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return !c.Date.Before(today)
+}
+
+// ParseCancellationsWiki returns all cancellations (past and upcoming) from the given wiki file, sorted by date and without duplicates.
+func ParseCancellationsWiki(cancellationsFilePath string) ([]Cancellation, error) {
 	data, err := os.ReadFile(cancellationsFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("while reading cancellations wiki file: %w", err)
 	}
 	wikiFileData := string(data)
 
-	cancellations := make(map[string][]Cancellation)
+	cancellations := make([]Cancellation, 0)
+	seen := make(map[string]struct{})
 
 	// Extract table rows with date, event and note cells.
 	rowRe := regexp.MustCompile(`(?is)<tr>\s*<td>\s*([0-9]{4}-[0-9]{2}-[0-9]{2})\s*</td>\s*<td>\s*(.*?)\s*</td>\s*<td>\s*.*?\s*</td>\s*<td>\s*(.*?)\s*</td>\s*</tr>`)
@@ -65,15 +107,60 @@ func ParseCancellationsWiki(cancellationsFilePath string) (map[string][]Cancella
 			continue
 		}
 
-		cancellations[eventName] = append(cancellations[eventName], Cancellation{
+		// the wiki sometimes lists the same cancellation twice
+		key := fmt.Sprintf("%s|%s", normalizeName(eventName), match[1])
+		if _, found := seen[key]; found {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		cancellations = append(cancellations, Cancellation{
 			Date:        date,
+			EventName:   eventName,
+			Reason:      ParseCancellationReason(description),
 			Description: description,
 		})
 	}
 
+	sort.SliceStable(cancellations, func(i, j int) bool {
+		return cancellations[i].Date.Before(cancellations[j].Date)
+	})
+
 	return cancellations, nil
 }
 
+// ApplyCancellations assigns the cancellations to the events (matched by normalized name or id) and splits them into upcoming and past ones.
+// It returns the cancellations that could not be matched to any event.
+func ApplyCancellations(cancellations []Cancellation, events []*Event, now time.Time) []Cancellation {
+	byName := make(map[string]*Event)
+	for _, event := range events {
+		byName[normalizeName(event.Id)] = event
+	}
+	for _, event := range events {
+		// the name has precedence over the id
+		byName[normalizeName(event.Name)] = event
+		event.Cancellations = nil
+		event.UpcomingCancellations = nil
+		event.PastCancellations = nil
+	}
+
+	unmatched := make([]Cancellation, 0)
+	for _, c := range cancellations {
+		event, found := byName[normalizeName(c.EventName)]
+		if !found {
+			unmatched = append(unmatched, c)
+			continue
+		}
+		event.Cancellations = append(event.Cancellations, c)
+		if c.Upcoming(now) {
+			event.UpcomingCancellations = append(event.UpcomingCancellations, c)
+		} else {
+			event.PastCancellations = append(event.PastCancellations, c)
+		}
+	}
+	return unmatched
+}
+
 func cleanupWikiCell(cell string) string {
 	withoutTags := regexp.MustCompile(`(?is)<[^>]*>`).ReplaceAllString(cell, "")
 	decoded := html.UnescapeString(withoutTags)
@@ -28,7 +28,7 @@ func TestCancellationReasonGerman(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			c := Cancellation{Description: tc.description}
+			c := Cancellation{Reason: ParseCancellationReason(tc.description), Description: tc.description}
 			if got := c.ReasonGerman(); got != tc.want {
 				t.Fatalf("ReasonGerman() = %q, want %q", got, tc.want)
 			}
@@ -79,10 +79,14 @@ func TestParseCancellationsWiki(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.wikiFile, func(t *testing.T) {
-			cancellations, err := ParseCancellationsWiki(tc.wikiFile)
+			list, err := ParseCancellationsWiki(tc.wikiFile)
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("ParseCancellationsWiki() error = %v, wantErr %v", err, tc.wantErr)
 			}
+			cancellations := make(map[string][]Cancellation)
+			for _, c := range list {
+				cancellations[c.EventName] = append(cancellations[c.EventName], c)
+			}
 
 			// check that the expected event names are present in the cancellations map
 			for eventName := range tc.wantCancellations {
@@ -125,3 +129,30 @@ func mustParseDate(t *testing.T, value string) time.Time {
 
 	return date
 }
+
+func TestApplyCancellations(t *testing.T) {
+	cancellations := []Cancellation{
+		{Date: mustParseDate(t, "2026-05-23"), EventName: "Fuldaaue parkrun", Reason: ReasonWeather},
+		{Date: mustParseDate(t, "2026-06-06"), EventName: "Fuldaaue parkrun", Reason: ReasonCourseUnsafe},
+		{Date: mustParseDate(t, "2026-06-13"), EventName: "Kulturpark  Neubrandenburg parkrun", Reason: ReasonVenueUnavailable},
+		{Date: mustParseDate(t, "2026-06-13"), EventName: "Unbekannter parkrun", Reason: ReasonOther},
+	}
+	fulda := &Event{Id: "fuldaaue", Name: "Fuldaaue parkrun"}
+	neubrandenburg := &Event{Id: "kulturparkneubrandenburg", Name: "Kulturpark Neubrandenburg parkrun"}
+	now := time.Date(2026, 6, 6, 8, 0, 0, 0, time.UTC)
+
+	unmatched := ApplyCancellations(cancellations, []*Event{fulda, neubrandenburg}, now)
+
+	if len(unmatched) != 1 || unmatched[0].EventName != "Unbekannter parkrun" {
+		t.Fatalf("unexpected unmatched cancellations: %+v", unmatched)
+	}
+	if len(fulda.Cancellations) != 2 || len(fulda.PastCancellations) != 1 || len(fulda.UpcomingCancellations) != 1 {
+		t.Fatalf("unexpected cancellations for fuldaaue: %+v", fulda)
+	}
+	if next := fulda.NextCancellation(); next == nil || next.DateF() != "06.06.2026" || next.ReasonGerman() != "Strecke unsicher oder gesperrt" {
+		t.Fatalf("unexpected next cancellation for fuldaaue: %+v", next)
+	}
+	if len(neubrandenburg.UpcomingCancellations) != 1 {
+		t.Fatalf("unexpected cancellations for kulturparkneubrandenburg: %+v", neubrandenburg)
+	}
+}
@@ -0,0 +1,185 @@
+package parkrun
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// CoursePage is the content of the course page of an event on the parkrun website.
+type CoursePage struct {
+	Description  string // course description; paragraphs are separated by empty lines
+	MapId        string // ID of the embedded Google My Maps map ("mid")
+	StartTime    string // e.g. "09:00"; empty if the page doesn't mention it
+	MeetingPoint string // location of the start; empty if the page doesn't mention it
+}
+
+// DescriptionParagraphs returns the paragraphs of the course description.
+func (page CoursePage) DescriptionParagraphs() []string {
+	if page.Description == "" {
+		return nil
+	}
+	return strings.Split(page.Description, "\n\n")
+}
+
+// course page sections by (lowercase) heading
+const (
+	courseSectionDescription  = "description"
+	courseSectionMeetingPoint = "meeting point"
+)
+
+func courseSection(heading string) string {
+	h := strings.ToLower(strings.Join(strings.Fields(heading), " "))
+	switch {
+	case containsAny(h, "streckenbeschreibung", "beschreibung der strecke", "course description"):
+		return courseSectionDescription
+	case containsAny(h, "startpunkt", "treffpunkt", "ort des starts", "lage des starts", "location of start", "start location", "meeting point"):
+		return courseSectionMeetingPoint
+	}
+	return ""
+}
+
+// e.g. "um 9:00 Uhr", "at 9.00am"
+var reCourseStartTime = regexp.MustCompile(`(?i)\b(\d{1,2})[:.](\d{2})\s*(uhr|am\b|a\.m\.)`)
+
+// courseBlock is a heading or a text block (paragraph, list item) of the course page.
+type courseBlock struct {
+	heading bool
+	text    string
+}
+
+func courseBlocks(n *html.Node) []courseBlock {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "script", "style", "nav", "header", "footer":
+			return nil
+		case "h1", "h2", "h3", "h4":
+			return []courseBlock{{heading: true, text: strings.Join(strings.Fields(nodeText(n)), " ")}}
+		case "p", "li":
+			if text := strings.Join(strings.Fields(nodeText(n)), " "); text != "" {
+				return []courseBlock{{text: text}}
+			}
+			return nil
+		}
+	}
+	var blocks []courseBlock
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		blocks = append(blocks, courseBlocks(child)...)
+	}
+	return blocks
+}
+
+// ParseCoursePage extracts the course description, the map ID, the start time and the meeting point from the course page.
+// Only the map is required; the texts are identified by the headings of their sections.
+func ParseCoursePage(buf []byte) (*CoursePage, error) {
+	doc, err := html.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("while parsing HTML: %w", err)
+	}
+
+	page := &CoursePage{}
+	for _, iframe := range findNodes(doc, func(n *html.Node) bool { return n.Data == "iframe" }) {
+		for _, attr := range iframe.Attr {
+			if attr.Key != "src" {
+				continue
+			}
+			u, err := url.Parse(attr.Val)
+			if err != nil || !strings.HasPrefix(u.Host, "www.google.") || !strings.HasPrefix(u.Path, "/maps/") {
+				continue
+			}
+			if mid := u.Query().Get("mid"); mid != "" && page.MapId == "" {
+				page.MapId = mid
+			}
+		}
+	}
+	if page.MapId == "" {
+		return nil, fmt.Errorf("cannot find map of course page")
+	}
+
+	section := ""
+	var description, meetingPoint []string
+	for _, block := range courseBlocks(doc) {
+		if block.heading {
+			section = courseSection(block.text)
+			continue
+		}
+		switch section {
+		case courseSectionDescription:
+			description = append(description, block.text)
+		case courseSectionMeetingPoint:
+			meetingPoint = append(meetingPoint, block.text)
+		}
+		if page.StartTime == "" && containsAny(strings.ToLower(block.text), "start", "beginn", "los") {
+			if m := reCourseStartTime.FindStringSubmatch(block.text); m != nil {
+				page.StartTime = fmt.Sprintf("%s:%s", strings.Repeat("0", 2-len(m[1]))+m[1], m[2])
+			}
+		}
+	}
+	page.Description = strings.Join(description, "\n\n")
+	page.MeetingPoint = strings.Join(meetingPoint, " ")
+
+	return page, nil
+}
+
+// LoadCoursePage parses the downloaded course page; the map is used as the course map if the data source has no route ID.
+func (event *Event) LoadCoursePage(filePath string) error {
+	buf, err := utils.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	page, err := ParseCoursePage(buf)
+	if err != nil {
+		return err
+	}
+	event.Course = page
+	event.GoogleMapsId = page.MapId
+	return nil
+}
+
+// CourseMismatch is a difference between the course page and the data source.
+type CourseMismatch struct {
+	Field      string // "route ID", "start time" or "meeting point"
+	CoursePage string
+	DataSource string
+}
+
+// significantWords returns the lowercase words of the text with at least 5 letters (e.g. names of parks or rivers).
+func significantWords(text string) []string {
+	words := make([]string, 0)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len([]rune(word)) >= 5 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// CourseMismatches compares the loaded course page with the data source: the route ID, the start time (09:00) and the
+// meeting point, which should mention the location of the course.
+func (event Event) CourseMismatches() []CourseMismatch {
+	page := event.Course
+	if page == nil {
+		return nil
+	}
+	mismatches := make([]CourseMismatch, 0)
+	if routeId := event.GoogleMapsCourseId(); page.MapId != routeId {
+		mismatches = append(mismatches, CourseMismatch{"route ID", page.MapId, routeId})
+	}
+	if page.StartTime != "" && page.StartTime != defaultStartTime {
+		mismatches = append(mismatches, CourseMismatch{"start time", page.StartTime, defaultStartTime})
+	}
+	if words := significantWords(event.SpecificLocation); page.MeetingPoint != "" && len(words) > 0 {
+		text := strings.ToLower(page.MeetingPoint + " " + page.Description)
+		if !containsAny(text, words...) {
+			mismatches = append(mismatches, CourseMismatch{"meeting point", page.MeetingPoint, event.SpecificLocation})
+		}
+	}
+	return mismatches
+}
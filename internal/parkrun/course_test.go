@@ -0,0 +1,77 @@
+package parkrun
+
+import (
+	"reflect"
+	"testing"
+)
+
+const coursePageHtml = `<html><body>
+<header><nav><ul><li>Start</li><li>Ergebnisse</li></ul></nav></header>
+<div class="page-content">
+<h1>Strecke</h1>
+<iframe src="https://www.google.com/maps/d/embed?mid=1P8MeMOlLX_4sh9iiES6auGwuNE1tgYo&amp;ehbc=2E312F" width="450" height="450"></iframe>
+<h2>Streckenbeschreibung</h2>
+<p>Die Strecke besteht aus
+   zwei Runden um den Dietenbachsee.</p>
+<p>Sie ist flach und <strong>asphaltiert</strong>.</p>
+<h2>Einrichtungen</h2>
+<p>Toiletten gibt es im Café.</p>
+<h2>Startpunkt</h2>
+<p>Wir treffen uns am Nordufer des Sees; der Lauf startet jeden Samstag um 9:00 Uhr.</p>
+</div>
+<footer><p>Start um 8:00 Uhr (footer)</p></footer>
+</body></html>`
+
+func TestParseCoursePage(t *testing.T) {
+	page, err := ParseCoursePage([]byte(coursePageHtml))
+	if err != nil {
+		t.Fatalf("ParseCoursePage() error = %v", err)
+	}
+	want := &CoursePage{
+		Description:  "Die Strecke besteht aus zwei Runden um den Dietenbachsee.\n\nSie ist flach und asphaltiert.",
+		MapId:        "1P8MeMOlLX_4sh9iiES6auGwuNE1tgYo",
+		StartTime:    "09:00",
+		MeetingPoint: "Wir treffen uns am Nordufer des Sees; der Lauf startet jeden Samstag um 9:00 Uhr.",
+	}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("ParseCoursePage() = %+v, want %+v", page, want)
+	}
+	if paragraphs := page.DescriptionParagraphs(); len(paragraphs) != 2 {
+		t.Fatalf("DescriptionParagraphs() = %q", paragraphs)
+	}
+
+	if _, err := ParseCoursePage([]byte("<html><body><h2>Streckenbeschreibung</h2><p>Ohne Karte.</p></body></html>")); err == nil {
+		t.Fatalf("ParseCoursePage() without map: no error")
+	}
+}
+
+func TestCourseMismatches(t *testing.T) {
+	page := CoursePage{Description: "Zwei Runden um den Dietenbachsee.", MapId: "abc", StartTime: "09:00", MeetingPoint: "Am Nordufer"}
+	testCases := []struct {
+		name     string
+		location string
+		mapsId   string
+		start    string
+		want     []CourseMismatch
+	}{
+		{"same", "am Dietenbachsee", "abc", "09:00", []CourseMismatch{}},
+		{"route", "", "xyz", "09:00", []CourseMismatch{{"route ID", "abc", "xyz"}}},
+		{"start time", "", "abc", "09:30", []CourseMismatch{{"start time", "09:30", "09:00"}}},
+		{"meeting point", "im Seepark", "abc", "", []CourseMismatch{{"meeting point", "Am Nordufer", "im Seepark"}}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			course := page
+			course.StartTime = tc.start
+			// the map ID of the course page is the fallback route ID of events without route ID in the data source
+			event := Event{Id: "course-test", SpecificLocation: tc.location, GoogleMapsId: tc.mapsId, Course: &course}
+			if got := event.CourseMismatches(); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("CourseMismatches() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+	if got := (Event{}).CourseMismatches(); got != nil {
+		t.Fatalf("CourseMismatches() without course page = %+v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package parkrun
 
 import (
+	"encoding/json"
 	"fmt"
 	"html"
 	"html/template"
@@ -80,7 +81,7 @@ type Run struct {
 }
 
 func (run Run) Url() string {
-	return fmt.Sprintf("https://parkrun.com.de/%s/results/%d/", run.Event.Id, run.Index)
+	return run.Event.Urls().Run(run.Index)
 }
 
 func (run Run) DateF() string {
@@ -245,51 +246,73 @@ type Event struct {
 	NearbyEvents                []*EventDistance
 	Current                     bool
 	Order                       int
-	Status                      string
+	Status                      Status
 	SummaryRegistrations        int
 	SummaryRunners              int
 	SummaryIndividualRunners    int
 	SummaryVolunteers           int
 	SummaryIndividualVolunteers int
-	Cancellations               []Cancellation
+	Summary                     *SummaryData   // row of the summary wiki page, nil if missing
+	Cancellations               []Cancellation // all cancellations, sorted by date
+	UpcomingCancellations       []Cancellation
+	PastCancellations           []Cancellation
+	Course                      *CoursePage // parsed course page, nil if not loaded
+}
+
+// NextCancellation returns the earliest upcoming cancellation, or nil.
+func (event Event) NextCancellation() *Cancellation {
+	if len(event.UpcomingCancellations) == 0 {
+		return nil
+	}
+	return &event.UpcomingCancellations[0]
 }
 
 func (event Event) Active() bool {
-	return event.Status == ""
+	return event.Status == StatusActive
 }
 func (event Event) Planned() bool {
-	return event.Status == "geplant"
+	return event.Status == StatusPlanned
 }
 func (event Event) TemporarilyClosed() bool {
-	return event.Status == "temporär geschlossen"
+	return event.Status == StatusTemporarilyClosed
 }
 func (event Event) Archived() bool {
 	return !event.Active() && !event.Planned()
 }
 
-func (event Event) Url() string {
-	if event.CountryUrl == "" {
-		return fmt.Sprintf("https://www.parkrun.com.de/%s", event.Id)
+// ArchivedDate is the date the event has been archived (zero if unknown or not archived).
+func (event Event) ArchivedDate() time.Time {
+	if info, ok := parkrun_infos[event.Id]; ok && event.Status == StatusArchived {
+		return info.Archived
 	}
-	return fmt.Sprintf("https://%s/%s", event.CountryUrl, event.Id)
+	return time.Time{}
 }
 
-func (event Event) CoursePageUrl() string {
-	if event.CountryUrl == "" {
-		return fmt.Sprintf("https://www.parkrun.com.de/%s/course", event.Id)
+func (event Event) ArchivedDateF() string {
+	if d := event.ArchivedDate(); !d.IsZero() {
+		return d.Format("02.01.2006")
 	}
-	return fmt.Sprintf("https://%s/%s/course", event.CountryUrl, event.Id)
+	return ""
+}
+
+func (event Event) Urls() UrlBuilder {
+	return NewUrlBuilder(event.CountryUrl, event.Id, event.Name)
+}
+
+func (event Event) Url() string {
+	return event.Urls().Home()
+}
+
+func (event Event) CoursePageUrl() string {
+	return event.Urls().Course()
 }
 
 func (event Event) ResultsUrl() string {
-	if event.CountryUrl == "" {
-		return fmt.Sprintf("https://www.parkrun.com.de/%s/results/eventhistory", event.Id)
-	}
-	return fmt.Sprintf("https://%s/%s/results/eventhistory", event.CountryUrl, event.Id)
+	return event.Urls().Results()
 }
 
 func (event Event) WikiUrl() string {
-	return fmt.Sprintf("https://wiki.parkrun.com/index.php/%s", strings.ReplaceAll(event.Name, " ", "_"))
+	return event.Urls().Wiki()
 }
 
 func (event Event) LastRun() string {
@@ -401,6 +424,23 @@ func (event *Event) PopulateNearby(events []*Event) {
 	}
 }
 
+// Cafe is the place where runners and volunteers meet after the run.
+type Cafe struct {
+	Name        string
+	GoogleMaps  string
+	Coordinates utils.Coordinates
+}
+
+func (cafe Cafe) Url() string {
+	if cafe.GoogleMaps != "" {
+		return cafe.GoogleMaps
+	}
+	if cafe.Coordinates.IsValid() {
+		return fmt.Sprintf("https://www.google.com/maps/search/?api=1&query=%f%%2C%f", cafe.Coordinates.Lat, cafe.Coordinates.Lon)
+	}
+	return ""
+}
+
 type ParkrunInfo struct {
 	Id          string
 	Name        string
@@ -413,9 +453,23 @@ type ParkrunInfo struct {
 	GoogleMaps  string
 	Updated     time.Time
 	First       string
-	Status      string
+	Status      Status
+	Archived    time.Time // explicit archived date (optional)
 	Coordinates string
 	Links       []Link
+	Cafe        *Cafe
+}
+
+// PlannedData is an entry of the list of planned parkruns that are not (yet) known to parkrun.
+type PlannedData struct {
+	Name      string
+	City      string
+	State     string
+	Status    PlannedStatus
+	Added     PlannedDate // announcement date
+	Start     PlannedDate // expected date of the first run
+	Instagram string
+	Link1     Link
 }
 
 func (info ParkrunInfo) ParseCoordinates() (utils.Coordinates, error) {
@@ -499,6 +553,14 @@ func (event Event) Links() []Link {
 	return nil
 }
 
+func (event Event) Cafe() *Cafe {
+	if info, ok := parkrun_infos[event.Id]; ok {
+		return info.Cafe
+	}
+
+	return nil
+}
+
 func (event Event) FixedName() string {
 	if info, ok := parkrun_infos[event.Id]; ok {
 		if info.Name != "" {
@@ -523,6 +585,20 @@ func (event Event) Outdated() bool {
 	return !event.Current
 }
 
+// LoadEventIds returns the ids of all events in events.json (optionally only the German ones).
+func LoadEventIds(events_json_file string, germanyOnly bool) (map[string]struct{}, error) {
+	entries, err := LoadEventsJson(events_json_file, germanyOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]struct{})
+	for _, e := range entries {
+		ids[e.Id] = struct{}{}
+	}
+	return ids, nil
+}
+
 func LoadEvents(events_json_file string, parkrun_infos_param map[string]*ParkrunInfo, germanyOnly bool) ([]*Event, error) {
 	parkrun_infos = parkrun_infos_param
 	buf, err := utils.ReadFile(events_json_file)
@@ -542,7 +618,7 @@ func LoadEvents(events_json_file string, parkrun_infos_param map[string]*Parkrun
 			continue
 		}
 
-		event := &Event{e.Name, e.LongName, e.Location, "", "", utils.Coordinates{Lat: e.Coordinates.Lat, Lon: e.Coordinates.Lng}, utils.InvalidCoordinates, e.Country.Url, "", "", nil, nil, nil, false, 0, "", 0, 0, 0, 0, 0, nil}
+		event := &Event{e.Name, e.LongName, e.Location, "", "", utils.Coordinates{Lat: e.Coordinates.Lat, Lon: e.Coordinates.Lng}, utils.InvalidCoordinates, e.Country.Url, "", "", nil, nil, nil, false, 0, "", 0, 0, 0, 0, 0, nil, nil, nil, nil, nil}
 		eventList = append(eventList, event)
 		eventMap[e.Name] = event
 	}
@@ -563,7 +639,7 @@ func LoadEvents(events_json_file string, parkrun_infos_param map[string]*Parkrun
 			event.RouteType = info.RouteType
 			continue
 		}
-		event := &Event{info.Id, info.Name, info.City, info.Location, template.HTML(info.Description), coordinates, utils.InvalidCoordinates, "", "", info.RouteType, nil, nil, nil, false, 0, info.Status, 0, 0, 0, 0, 0, nil}
+		event := &Event{info.Id, info.Name, info.City, info.Location, template.HTML(info.Description), coordinates, utils.InvalidCoordinates, "", "", info.RouteType, nil, nil, nil, false, 0, info.Status, 0, 0, 0, 0, 0, nil, nil, nil, nil, nil}
 		eventList = append(eventList, event)
 	}
 
@@ -752,29 +828,6 @@ func (event *Event) parseWikiSummaryTable(lines []string) error {
 	return nil
 }
 
-// <iframe src="https://www.google.com/maps/d/embed?t=h&mid=1jzu9KWQBw__FbZHD3RW6KqLY9CxMzQAa" width="450" height="450"></iframe>
-var reMapsId = regexp.MustCompile(`<iframe src="https://www\.google\.[^/]+/maps/[^"]*mid=([^"&]+)("|&)`)
-
-func (event *Event) LoadCoursePage(filePath string) error {
-	buf, err := utils.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-	sbuf := string(buf)
-
-	event.GoogleMapsId = ""
-	for _, line := range strings.Split(sbuf, "\n") {
-		if m := reMapsId.FindStringSubmatch(line); m != nil {
-			event.GoogleMapsId = m[1]
-		}
-	}
-
-	if event.GoogleMapsId == "" {
-		return fmt.Errorf("cannot find map of course page")
-	}
-	return nil
-}
-
 func escape(s string) string {
 	return strings.ReplaceAll(s, "\\", "\\\\")
 }
@@ -872,6 +925,15 @@ func RenderJs(events []*Event, filePath string) error {
 		fmt.Fprintf(out, "\"lat\": %.5f, \"lon\": %f,\n", event.Coords.Lat, event.Coords.Lon)
 		fmt.Fprintf(out, "\"location\": \"%s\",\n", escapeQuotes(event.FixedLocation()))
 		fmt.Fprintf(out, "\"googleMapsUrl\": \"%s\",\n", event.GoogleMapsUrl())
+		if cafe := event.Cafe(); cafe != nil {
+			fmt.Fprintf(out, "\"cafe\": {\"name\": \"%s\", \"url\": \"%s\"", escapeQuotes(cafe.Name), cafe.Url())
+			if cafe.Coordinates.IsValid() {
+				fmt.Fprintf(out, ", \"lat\": %.5f, \"lon\": %.5f", cafe.Coordinates.Lat, cafe.Coordinates.Lon)
+			}
+			fmt.Fprintf(out, "},\n")
+		} else {
+			fmt.Fprintf(out, "\"cafe\": null,\n")
+		}
 		fmt.Fprintf(out, "\"tracks\": [")
 		for it, track := range event.Tracks {
 			if it != 0 {
@@ -903,6 +965,11 @@ func RenderJs(events []*Event, filePath string) error {
 		} else {
 			fmt.Fprintf(out, "\"temporarily_closed\": false,\n")
 		}
+		if next := event.NextCancellation(); next != nil {
+			fmt.Fprintf(out, "\"cancellation\": {\"date\": \"%s\", \"reason\": \"%s\"},\n", next.DateF(), escapeQuotes(next.ReasonGerman()))
+		} else {
+			fmt.Fprintf(out, "\"cancellation\": null,\n")
+		}
 		if event.LatestRun != nil {
 			fmt.Fprintf(out, "\"latest\": {\n")
 			fmt.Fprintf(out, "\"index\": %d,\n", event.LatestRun.Index)
@@ -936,10 +1003,19 @@ func ExportCsv(events []*Event, filePath string) error {
 	defer out.Close()
 
 	// export base data
-	fmt.Fprintf(out, "id;name;city;state;location;status;first;coordinates;route_type;google_route_id;google_maps_url;link1;link2;link3;link4;link5\n")
+	fmt.Fprintf(out, "id;name;city;state;location;status;first;coordinates;route_type;google_route_id;google_maps_url;cafe;cafe_google_maps_url;cafe_coordinates;link1;link2;link3;link4;link5\n")
 
 	for _, event := range events {
 		fmt.Fprintf(out, "\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\";\"%s\"", event.Id, escapeQuotes(event.FixedName()), escapeQuotes(event.FixedLocation()), escapeQuotes(event.State()), escapeQuotes(event.SpecificLocation), event.Status, event.First(), event.Coordinates(), event.RouteType, event.GoogleMapsCourseId(), event.GoogleMapsUrl())
+		if cafe := event.Cafe(); cafe != nil {
+			cafeCoordinates := ""
+			if cafe.Coordinates.IsValid() {
+				cafeCoordinates = fmt.Sprintf("%f,%f", cafe.Coordinates.Lat, cafe.Coordinates.Lon)
+			}
+			fmt.Fprintf(out, ";\"%s\";\"%s\";\"%s\"", escapeQuotes(cafe.Name), cafe.GoogleMaps, cafeCoordinates)
+		} else {
+			fmt.Fprintf(out, ";\"\";\"\";\"\"")
+		}
 		for _, link := range event.Links() {
 			fmt.Fprintf(out, ";\"%s|%s\"", escapeQuotes(link.Name), escapeQuotes(link.Url))
 		}
@@ -948,3 +1024,66 @@ func ExportCsv(events []*Event, filePath string) error {
 
 	return nil
 }
+
+type geoJsonGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJsonFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJsonGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJsonFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJsonFeature `json:"features"`
+}
+
+func ExportGeoJson(events []*Event, filePath string) error {
+	collection := geoJsonFeatureCollection{Type: "FeatureCollection", Features: make([]geoJsonFeature, 0, len(events))}
+	for _, event := range events {
+		if !event.Coords.IsValid() {
+			continue
+		}
+
+		properties := map[string]any{
+			"id":              event.Id,
+			"name":            event.FixedName(),
+			"city":            event.FixedLocation(),
+			"state":           event.State(),
+			"location":        event.SpecificLocation,
+			"status":          string(event.Status),
+			"first":           event.First(),
+			"route_type":      event.RouteType,
+			"google_route_id": event.GoogleMapsCourseId(),
+			"google_maps_url": event.GoogleMapsUrl(),
+			"url":             event.Url(),
+		}
+		if cafe := event.Cafe(); cafe != nil {
+			properties["cafe"] = cafe.Name
+			properties["cafe_google_maps_url"] = cafe.GoogleMaps
+			if cafe.Coordinates.IsValid() {
+				properties["cafe_coordinates"] = []float64{cafe.Coordinates.Lon, cafe.Coordinates.Lat}
+			}
+		}
+		links := make([]map[string]string, 0)
+		for _, link := range event.Links() {
+			links = append(links, map[string]string{"name": link.Name, "url": link.Url})
+		}
+		properties["links"] = links
+
+		collection.Features = append(collection.Features, geoJsonFeature{
+			Type:       "Feature",
+			Geometry:   geoJsonGeometry{Type: "Point", Coordinates: []float64{event.Coords.Lon, event.Coords.Lat}},
+			Properties: properties,
+		})
+	}
+
+	buf, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, buf)
+}
@@ -0,0 +1,96 @@
+package parkrun
+
+import (
+	"time"
+)
+
+// WikiFetch is the decision whether (and how eagerly) an event's wiki page should be downloaded.
+type WikiFetch struct {
+	Fetch    bool
+	MaxAge   time.Duration // download only if the cached file is older
+	Optional bool          // download errors are not fatal (planned events)
+	Reason   string
+}
+
+// WikiSchedule decides when the wiki pages of the events have to be refreshed.
+// The results of a run day are expected one hour after its start; before that, nothing new can be fetched.
+type WikiSchedule struct {
+	Now         time.Time
+	LatestDate  time.Time // latest run date of all cached wiki pages
+	SummaryTime time.Time // download time of the summary wiki page
+	SpecialDays *SpecialDays
+}
+
+// ResultsPublished returns true if 'now' is on a run day, at least one hour after the start.
+func (days *SpecialDays) ResultsPublished(now time.Time) bool {
+	start, ok := days.RunDayStartTime(now)
+	return ok && !now.Before(start.Add(time.Hour))
+}
+
+// summaryIsFresh returns true if the summary wiki page has been downloaded after today's results (if any) have been published.
+func (s WikiSchedule) summaryIsFresh() bool {
+	if !s.SpecialDays.ResultsPublished(s.Now) {
+		return true
+	}
+	start, _ := s.SpecialDays.RunDayStartTime(s.Now)
+	return !s.SummaryTime.Before(start.Add(time.Hour))
+}
+
+// eventResultsPublished returns true if the event's results of today are expected, i.e. 'now' is at least one hour after
+// the event's start on a run day (special days may have different start times).
+func (s WikiSchedule) eventResultsPublished(event *Event) bool {
+	if !s.SpecialDays.IsRunDay(s.Now) {
+		return false
+	}
+	start, err := time.Parse("15:04", event.runningOn(s.SpecialDays, s.Now).Start)
+	if err != nil {
+		return s.SpecialDays.ResultsPublished(s.Now)
+	}
+	startTime := time.Date(s.Now.Year(), s.Now.Month(), s.Now.Day(), start.Hour(), start.Minute(), 0, 0, s.Now.Location())
+	return !s.Now.Before(startTime.Add(time.Hour))
+}
+
+// expectsRun returns true if a run of the event is expected on the given date. On special days without a list of events,
+// a run is only expected if other events already published results for that day.
+func (s WikiSchedule) expectsRun(event *Event, date time.Time) bool {
+	status := event.runningOn(s.SpecialDays, date)
+	return status.Running || (status.Unknown && sameDay(s.LatestDate, date))
+}
+
+// Decide determines whether the wiki page of the event has to be downloaded, given the latest run of the cached page
+// (nil if there's no usable cached page) and the event's row of the summary wiki page (nil if missing).
+func (s WikiSchedule) Decide(event *Event, cached *Run, summary *SummaryData) WikiFetch {
+	today := time.Date(s.Now.Year(), s.Now.Month(), s.Now.Day(), 0, 0, 0, 0, time.UTC)
+	published := s.eventResultsPublished(event)
+
+	switch {
+	case event.Planned():
+		if first, ok := event.firstRunDate(); ok && sameDay(first, today) && published {
+			return WikiFetch{Fetch: true, MaxAge: time.Hour, Optional: true, Reason: "first run today"}
+		}
+		return WikiFetch{Reason: "planned"}
+	case !event.Active():
+		return WikiFetch{Reason: "not active"}
+	}
+
+	if cached != nil && cached.RunnerCount == 0 {
+		cached = nil
+	}
+	if summary != nil && summary.Covers(cached) && s.summaryIsFresh() {
+		return WikiFetch{Reason: "latest according to summary wiki"}
+	}
+	if cached == nil {
+		return WikiFetch{Fetch: true, MaxAge: time.Hour, Reason: "no usable cached data"}
+	}
+	if published && s.expectsRun(event, today) && !sameDay(cached.Date, today) {
+		return WikiFetch{Fetch: true, MaxAge: time.Hour, Reason: "results of today expected"}
+	}
+	if s.LatestDate.After(cached.Date) && s.expectsRun(event, s.LatestDate) {
+		return WikiFetch{Fetch: true, MaxAge: time.Hour, Reason: "other events have newer results"}
+	}
+	if !published && event.RunsOn(s.SpecialDays, today) {
+		// the cached page can't be outdated before today's results are published
+		return WikiFetch{Reason: "waiting for today's results"}
+	}
+	return WikiFetch{Fetch: true, MaxAge: 24 * time.Hour, Reason: "daily refresh"}
+}
@@ -0,0 +1,92 @@
+package parkrun
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWikiScheduleDecide(t *testing.T) {
+	specialDays := &SpecialDays{Days: []*SpecialDay{
+		{Date: "12-25", Name: "Weihnachten"},
+		{Date: "01-01", Name: "Neujahr", Events: []SpecialDayEvent{{Id: "seepark", Start: "10:00"}}},
+	}}
+	infos := parkrun_infos
+	parkrun_infos = map[string]*ParkrunInfo{"neu": {Id: "neu", First: "06.06.2026"}}
+	defer func() { parkrun_infos = infos }()
+
+	at := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", s)
+		return t
+	}
+	day := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	run := func(date string, index int, runners int) *Run {
+		return &Run{Index: index, Date: day(date), RunnerCount: runners}
+	}
+
+	active := &Event{Id: "dietenbach", Name: "Dietenbach parkrun"}
+	listed := &Event{Id: "seepark", Name: "Seepark parkrun"}
+	cancelled := &Event{Id: "fuldaaue", Name: "Fuldaaue parkrun", Cancellations: []Cancellation{{Date: day("2026-06-06"), Reason: ReasonCourseUnsafe}}}
+	planned := &Event{Id: "neu", Name: "Neu parkrun", Status: StatusPlanned}
+	otherPlanned := &Event{Id: "bald", Name: "Bald parkrun", Status: StatusPlanned}
+	archived := &Event{Id: "alt", Name: "Alt parkrun", Status: StatusArchived}
+
+	testCases := []struct {
+		name       string
+		event      *Event
+		now        string
+		latestDate string
+		summaryAt  string
+		cached     *Run
+		summary    *SummaryData
+		want       bool
+		wantMaxAge time.Duration
+		wantReason string
+	}{
+		{name: "friday", event: active, now: "2026-06-05 12:00", latestDate: "2026-05-30", cached: run("2026-05-30", 100, 50), want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "friday, summary matches", event: active, now: "2026-06-05 12:00", latestDate: "2026-05-30", cached: run("2026-05-30", 100, 50), summary: &SummaryData{LatestEventId: 100, Runners: 50}, wantReason: "latest according to summary wiki"},
+		{name: "friday, summary differs", event: active, now: "2026-06-05 12:00", latestDate: "2026-05-30", cached: run("2026-05-30", 100, 50), summary: &SummaryData{LatestEventId: 100, Runners: 51}, want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "friday, summary has newer run", event: active, now: "2026-06-05 12:00", latestDate: "2026-05-30", cached: run("2026-05-23", 99, 50), summary: &SummaryData{LatestEventId: 100, LatestDate: day("2026-05-30"), Runners: 51}, wantReason: "latest according to summary wiki"},
+		{name: "no cached data, summary has run", event: active, now: "2026-06-05 12:00", summary: &SummaryData{LatestEventId: 100, LatestDate: day("2026-05-30"), Runners: 51}, wantReason: "latest according to summary wiki"},
+		{name: "no cached data", event: active, now: "2026-06-05 12:00", want: true, wantMaxAge: time.Hour, wantReason: "no usable cached data"},
+		{name: "cached data without runners", event: active, now: "2026-06-05 12:00", cached: run("2026-05-30", 100, 0), want: true, wantMaxAge: time.Hour, wantReason: "no usable cached data"},
+		{name: "saturday morning", event: active, now: "2026-06-06 08:30", latestDate: "2026-05-30", cached: run("2026-05-30", 100, 50), wantReason: "waiting for today's results"},
+		{name: "saturday morning, outdated", event: active, now: "2026-06-06 08:30", latestDate: "2026-05-30", cached: run("2026-05-23", 99, 50), want: true, wantMaxAge: time.Hour, wantReason: "other events have newer results"},
+		{name: "saturday after results", event: active, now: "2026-06-06 11:00", latestDate: "2026-05-30", cached: run("2026-05-30", 100, 50), want: true, wantMaxAge: time.Hour, wantReason: "results of today expected"},
+		{name: "saturday after results, stale summary", event: active, now: "2026-06-06 11:00", latestDate: "2026-05-30", summaryAt: "2026-06-06 07:00", cached: run("2026-05-30", 100, 50), summary: &SummaryData{LatestEventId: 100, Runners: 50}, want: true, wantMaxAge: time.Hour, wantReason: "results of today expected"},
+		{name: "saturday after results, fresh summary", event: active, now: "2026-06-06 11:00", latestDate: "2026-05-30", summaryAt: "2026-06-06 10:30", cached: run("2026-05-30", 100, 50), summary: &SummaryData{LatestEventId: 100, Runners: 50}, wantReason: "latest according to summary wiki"},
+		{name: "saturday, results fetched", event: active, now: "2026-06-06 11:00", latestDate: "2026-06-06", cached: run("2026-06-06", 101, 50), want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "saturday, cancelled", event: cancelled, now: "2026-06-06 11:00", latestDate: "2026-06-06", cached: run("2026-05-30", 100, 50), want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "sunday, other events newer", event: active, now: "2026-06-07 12:00", latestDate: "2026-06-06", cached: run("2026-05-30", 100, 50), want: true, wantMaxAge: time.Hour, wantReason: "other events have newer results"},
+		{name: "christmas morning", event: active, now: "2026-12-25 08:00", latestDate: "2026-12-19", cached: run("2026-12-19", 100, 50), wantReason: "waiting for today's results"},
+		{name: "christmas, no results yet", event: active, now: "2026-12-25 11:00", latestDate: "2026-12-19", cached: run("2026-12-19", 100, 50), want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "christmas, other events ran", event: active, now: "2026-12-25 11:00", latestDate: "2026-12-25", cached: run("2026-12-19", 100, 50), want: true, wantMaxAge: time.Hour, wantReason: "results of today expected"},
+		{name: "new year's day, before start", event: listed, now: "2027-01-01 10:30", latestDate: "2026-12-26", cached: run("2026-12-26", 100, 50), wantReason: "waiting for today's results"},
+		{name: "new year's day, listed", event: listed, now: "2027-01-01 11:30", latestDate: "2026-12-26", cached: run("2026-12-26", 100, 50), want: true, wantMaxAge: time.Hour, wantReason: "results of today expected"},
+		{name: "new year's day, not listed", event: active, now: "2027-01-01 11:30", latestDate: "2027-01-01", cached: run("2026-12-26", 100, 50), want: true, wantMaxAge: 24 * time.Hour, wantReason: "daily refresh"},
+		{name: "planned, first run today", event: planned, now: "2026-06-06 11:00", want: true, wantMaxAge: time.Hour, wantReason: "first run today"},
+		{name: "planned, first run this morning", event: planned, now: "2026-06-06 08:00", wantReason: "planned"},
+		{name: "planned, other day", event: planned, now: "2026-05-30 11:00", wantReason: "planned"},
+		{name: "planned without date", event: otherPlanned, now: "2026-06-06 11:00", wantReason: "planned"},
+		{name: "archived", event: archived, now: "2026-06-06 11:00", wantReason: "not active"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := WikiSchedule{Now: at(tc.now), SummaryTime: at(tc.summaryAt), SpecialDays: specialDays}
+			if tc.latestDate != "" {
+				schedule.LatestDate = day(tc.latestDate)
+			}
+			got := schedule.Decide(tc.event, tc.cached, tc.summary)
+			if got.Fetch != tc.want || got.MaxAge != tc.wantMaxAge || got.Reason != tc.wantReason {
+				t.Fatalf("Decide() = %v/%v (%s), want %v/%v (%s)", got.Fetch, got.MaxAge, got.Reason, tc.want, tc.wantMaxAge, tc.wantReason)
+			}
+			if got.Optional != tc.event.Planned() && got.Fetch {
+				t.Fatalf("Decide() optional = %v, want %v", got.Optional, tc.event.Planned())
+			}
+		})
+	}
+}
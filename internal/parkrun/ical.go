@@ -0,0 +1,234 @@
+package parkrun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const icalTimezone = "Europe/Berlin"
+
+// VTIMEZONE definition of Europe/Berlin (CET/CEST, EU DST rules)
+const icalVTimezone = "BEGIN:VTIMEZONE\r\n" +
+	"TZID:Europe/Berlin\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"TZNAME:CEST\r\n" +
+	"DTSTART:19700329T020000\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"TZNAME:CET\r\n" +
+	"DTSTART:19701025T030000\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n"
+
+type icalWriter struct {
+	sb strings.Builder
+}
+
+func icalEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// line writes a content line, folded at 75 octets as required by RFC 5545; continuation lines start with a space, so
+// they carry at most 74 octets of the content.
+func (w *icalWriter) line(format string, args ...any) {
+	s := fmt.Sprintf(format, args...)
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// don't split UTF-8 sequences
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut -= 1
+		}
+		w.sb.WriteString(s[:cut])
+		w.sb.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	w.sb.WriteString(s)
+	w.sb.WriteString("\r\n")
+}
+
+func icalLocalTime(date time.Time) string {
+	return icalLocalTimeAt(date, defaultStartTime)
+}
+
+// icalLocalTimeAt formats the date with the given start time ("HH:MM").
+func icalLocalTimeAt(date time.Time, start string) string {
+	t, err := time.Parse("15:04", start)
+	if err != nil {
+		t, _ = time.Parse("15:04", defaultStartTime)
+	}
+	return fmt.Sprintf("%04d%02d%02dT%02d%02d00", date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute())
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+// previousSaturday returns the latest Saturday on or before the given date.
+func previousSaturday(date time.Time) time.Time {
+	offset := (int(date.Weekday()) - int(time.Saturday) + 7) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// firstRunDate returns the (possibly planned) date of the first run.
+func (event Event) firstRunDate() (time.Time, bool) {
+	if m := reDateDDMMYYYY.FindStringSubmatch(event.First()); m != nil {
+		if date, err := time.Parse("02.01.2006", fmt.Sprintf("%s.%s.%s", m[1], m[2], m[3])); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (event Event) cancelledOn(date time.Time) bool {
+	for _, c := range event.Cancellations {
+		if sameDay(c.Date, date) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *icalWriter) writeEvent(event *Event, days *SpecialDays, domain string, pageUrl string, now time.Time) {
+	if !event.Active() && !event.Planned() {
+		return
+	}
+
+	start, hasStart := event.firstRunDate()
+	if !hasStart {
+		if event.Planned() {
+			// no date => nothing to put into the calendar
+			return
+		}
+		if event.LatestRun != nil {
+			start = event.LatestRun.Date
+		} else {
+			start = previousSaturday(now)
+		}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	specialRuns := event.SpecialRuns(days, today, today.AddDate(1, 0, 0), start)
+
+	stamp := ICalStamp(now)
+	location := event.FixedLocation()
+	if event.SpecificLocation != "" {
+		location = fmt.Sprintf("%s, %s", event.SpecificLocation, event.FixedLocation())
+	}
+
+	// weekly run
+	w.line("BEGIN:VEVENT")
+	w.line("UID:%s-weekly@%s", event.Id, domain)
+	w.line("DTSTAMP:%s", stamp)
+	w.line("SUMMARY:%s", icalEscape(event.FixedName()))
+	w.line("DESCRIPTION:%s", icalEscape(fmt.Sprintf("Kostenloser, wöchentlicher 5km-Lauf.\n%s", pageUrl)))
+	w.line("LOCATION:%s", icalEscape(location))
+	if event.Coords.IsValid() {
+		w.line("GEO:%.6f;%.6f", event.Coords.Lat, event.Coords.Lon)
+	}
+	w.line("URL:%s", pageUrl)
+	w.line("DTSTART;TZID=%s:%s", icalTimezone, icalLocalTime(start))
+	w.line("DURATION:PT1H")
+	w.line("RRULE:FREQ=WEEKLY;BYDAY=SA")
+	if event.Planned() {
+		// the first run has its own entry (see below)
+		w.line("EXDATE;TZID=%s:%s", icalTimezone, icalLocalTime(start))
+	}
+	for _, c := range event.Cancellations {
+		if c.Date.Weekday() == time.Saturday {
+			w.line("EXDATE;TZID=%s:%s", icalTimezone, icalLocalTime(c.Date))
+		}
+	}
+	// special days on Saturdays replace the regular run if the event doesn't run or starts at a different time
+	for _, special := range specialRuns {
+		if special.Date.Weekday() == time.Saturday && (!special.Runs || special.Start != defaultStartTime) && !event.cancelledOn(special.Date) {
+			w.line("EXDATE;TZID=%s:%s", icalTimezone, icalLocalTime(special.Date))
+		}
+	}
+	w.line("END:VEVENT")
+
+	// first run of a planned event
+	if event.Planned() {
+		w.line("BEGIN:VEVENT")
+		w.line("UID:%s-first@%s", event.Id, domain)
+		w.line("DTSTAMP:%s", stamp)
+		w.line("SUMMARY:%s", icalEscape(fmt.Sprintf("%s: Erster Lauf", event.FixedName())))
+		w.line("LOCATION:%s", icalEscape(location))
+		w.line("URL:%s", pageUrl)
+		w.line("DTSTART;TZID=%s:%s", icalTimezone, icalLocalTime(start))
+		w.line("DURATION:PT1H")
+		w.line("END:VEVENT")
+	}
+
+	// special days within the next year (on Saturdays only if the start time differs from the regular run)
+	for _, special := range specialRuns {
+		if !special.Runs || (special.Date.Weekday() == time.Saturday && special.Start == defaultStartTime) {
+			continue
+		}
+		description := fmt.Sprintf("Sonderlauf an %s.\n%s", special.Day.Name, pageUrl)
+		if !special.Day.KnownEvents() {
+			description = fmt.Sprintf("Sonderlauf an %s - bitte die Ankündigung des Standorts beachten.\n%s", special.Day.Name, pageUrl)
+		}
+		w.line("BEGIN:VEVENT")
+		w.line("UID:%s-%s@%s", event.Id, special.Date.Format("20060102"), domain)
+		w.line("DTSTAMP:%s", stamp)
+		w.line("SUMMARY:%s", icalEscape(fmt.Sprintf("%s (%s)", event.FixedName(), special.Day.Name)))
+		w.line("DESCRIPTION:%s", icalEscape(description))
+		w.line("LOCATION:%s", icalEscape(location))
+		w.line("URL:%s", pageUrl)
+		w.line("DTSTART;TZID=%s:%s", icalTimezone, icalLocalTimeAt(special.Date, special.Start))
+		w.line("DURATION:PT1H")
+		if event.cancelledOn(special.Date) {
+			w.line("STATUS:CANCELLED")
+		}
+		w.line("END:VEVENT")
+	}
+}
+
+func (w *icalWriter) writeCalendar(name string, events []*Event, days *SpecialDays, domain string, pageUrl func(*Event) string, now time.Time) {
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//%s//parkrun-map//DE", domain)
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	w.line("X-WR-CALNAME:%s", icalEscape(name))
+	w.line("X-WR-TIMEZONE:%s", icalTimezone)
+	w.sb.WriteString(icalVTimezone)
+	for _, event := range events {
+		w.writeEvent(event, days, domain, pageUrl(event), now)
+	}
+	w.line("END:VCALENDAR")
+}
+
+// ICal returns an iCalendar file with the weekly runs, cancellations and special runs of a single event; pageUrl is the
+// canonical URL of the event's page.
+func ICal(event *Event, days *SpecialDays, domain string, pageUrl string, now time.Time) []byte {
+	w := icalWriter{}
+	w.writeCalendar(event.FixedName(), []*Event{event}, days, domain, func(*Event) string { return pageUrl }, now)
+	return []byte(w.sb.String())
+}
+
+// ICalAll returns an iCalendar file with the weekly runs, cancellations and special runs of all events; pageUrl returns
+// the canonical URL of an event's page.
+func ICalAll(events []*Event, days *SpecialDays, domain string, pageUrl func(*Event) string, now time.Time) []byte {
+	w := icalWriter{}
+	w.writeCalendar("Alle parkruns in Deutschland", events, days, domain, pageUrl, now)
+	return []byte(w.sb.String())
+}
+
+// ICalStamp is the DTSTAMP of the iCalendar files rendered at the given time.
+func ICalStamp(now time.Time) string {
+	return now.UTC().Format("20060102T150405Z")
+}
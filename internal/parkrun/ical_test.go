@@ -0,0 +1,141 @@
+package parkrun
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalWriteEvent(t *testing.T) {
+	now := time.Date(2026, 11, 10, 12, 0, 0, 0, time.UTC)
+	event := &Event{
+		Id:       "dietenbach",
+		Name:     "Dietenbach parkrun",
+		Location: "Freiburg",
+		LatestRun: &Run{
+			Index: 100,
+			Date:  time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC),
+		},
+		Cancellations: []Cancellation{
+			{Date: time.Date(2026, 11, 21, 0, 0, 0, 0, time.UTC), Description: "Weather"},
+			{Date: time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC), Description: "Venue unavailable"},
+		},
+	}
+	event.LatestRun.Event = event
+
+	w := icalWriter{}
+	w.writeCalendar("test", []*Event{event}, DefaultSpecialDays(), "parkruns.de", func(event *Event) string { return "https://parkruns.de/" + event.Id + ".html" }, now)
+	content := w.sb.String()
+
+	for _, want := range []string{
+		"UID:dietenbach-weekly@parkruns.de\r\n",
+		"DTSTART;TZID=Europe/Berlin:20261107T090000\r\n",
+		"RRULE:FREQ=WEEKLY;BYDAY=SA\r\n",
+		"EXDATE;TZID=Europe/Berlin:20261121T090000\r\n",
+		"UID:dietenbach-20261225@parkruns.de\r\n",
+		"UID:dietenbach-20270101@parkruns.de\r\n",
+		"STATUS:CANCELLED\r\n",
+		"URL:https://parkruns.de/dietenbach.html\r\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("missing %q in calendar:\n%s", want, content)
+		}
+	}
+
+	// the cancelled special day is no Saturday, so it must not show up as EXDATE
+	if strings.Contains(content, "EXDATE;TZID=Europe/Berlin:20261225T090000") {
+		t.Fatalf("unexpected EXDATE for special day:\n%s", content)
+	}
+
+	for _, line := range strings.Split(content, "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line exceeds 75 octets: %q", line)
+		}
+	}
+}
+
+func TestICalLineFolding(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"short", "SUMMARY:Dietenbach parkrun"},
+		{"ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"utf-8", "DESCRIPTION:" + strings.Repeat("Südpark Übersicht ", 12)},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			w := icalWriter{}
+			w.line("%s", tc.content)
+			folded := strings.TrimSuffix(w.sb.String(), "\r\n")
+			for _, line := range strings.Split(folded, "\r\n") {
+				if len(line) > 75 {
+					t.Fatalf("line exceeds 75 octets (%d): %q", len(line), line)
+				}
+			}
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tc.content {
+				t.Fatalf("unfolded line = %q, want %q", unfolded, tc.content)
+			}
+		})
+	}
+}
+
+func TestICalWriteEventPlanned(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// planned events without a known start date are skipped
+	w := icalWriter{}
+	w.writeEvent(&Event{Id: "stadtparkrotehorn", Name: "Stadtpark Rotehorn parkrun", Status: "geplant"}, DefaultSpecialDays(), "parkruns.de", "https://parkruns.de/stadtparkrotehorn", now)
+	if content := w.sb.String(); content != "" {
+		t.Fatalf("expected no calendar entries, got:\n%s", content)
+	}
+
+	// the first run is a separate entry and not part of the weekly series
+	infos := parkrun_infos
+	defer func() { parkrun_infos = infos }()
+	parkrun_infos = map[string]*ParkrunInfo{"stadtparkrotehorn": {Id: "stadtparkrotehorn", First: "14.03.2026"}}
+	w = icalWriter{}
+	w.writeEvent(&Event{Id: "stadtparkrotehorn", Name: "Stadtpark Rotehorn parkrun", Status: "geplant"}, DefaultSpecialDays(), "parkruns.de", "https://parkruns.de/stadtparkrotehorn", now)
+	content := w.sb.String()
+	if count := strings.Count(content, "DTSTART;TZID=Europe/Berlin:20260314T090000"); count != 2 {
+		t.Fatalf("expected the weekly series and the first run to start on 2026-03-14, got:\n%s", content)
+	}
+	if !strings.Contains(content, "EXDATE;TZID=Europe/Berlin:20260314T090000") {
+		t.Fatalf("expected the first run to be excluded from the weekly series, got:\n%s", content)
+	}
+	if !strings.Contains(content, "UID:stadtparkrotehorn-first@parkruns.de") {
+		t.Fatalf("expected an entry for the first run, got:\n%s", content)
+	}
+}
+
+func TestICalSaturdaySpecialDay(t *testing.T) {
+	// 2026-10-03 is a Saturday; only seepark holds a (later) extra run
+	days := &SpecialDays{Days: []*SpecialDay{
+		{Date: "10-03", Name: "Tag der Deutschen Einheit", Events: []SpecialDayEvent{{Id: "seepark", Start: "10:00"}}},
+	}}
+
+	now := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	latest := &Run{Index: 100, Date: time.Date(2026, 8, 29, 0, 0, 0, 0, time.UTC)}
+	testCases := []struct {
+		id         string
+		wantExdate bool
+	}{
+		{"dietenbach", false},
+		{"seepark", true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.id, func(t *testing.T) {
+			w := icalWriter{}
+			w.writeEvent(&Event{Id: tc.id, Name: tc.id, LatestRun: latest}, days, "parkruns.de", "https://parkruns.de/"+tc.id, now)
+			content := w.sb.String()
+			if got := strings.Contains(content, "EXDATE;TZID=Europe/Berlin:20261003T090000"); got != tc.wantExdate {
+				t.Fatalf("EXDATE for 2026-10-03 = %v, want %v:\n%s", got, tc.wantExdate, content)
+			}
+			if got := strings.Contains(content, "UID:"+tc.id+"-20261003@parkruns.de"); got != tc.wantExdate {
+				t.Fatalf("extra run on 2026-10-03 = %v, want %v:\n%s", got, tc.wantExdate, content)
+			}
+		})
+	}
+}
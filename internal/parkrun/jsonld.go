@@ -0,0 +1,171 @@
+package parkrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+)
+
+// schema.org structures, see https://schema.org/SportsEvent
+
+type jsonLdGeoCoordinates struct {
+	Type      string  `json:"@type"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type jsonLdPostalAddress struct {
+	Type            string `json:"@type"`
+	AddressLocality string `json:"addressLocality"`
+	AddressRegion   string `json:"addressRegion,omitempty"`
+	AddressCountry  string `json:"addressCountry"`
+}
+
+type jsonLdPlace struct {
+	Type    string                `json:"@type"`
+	Name    string                `json:"name"`
+	Address jsonLdPostalAddress   `json:"address"`
+	Geo     *jsonLdGeoCoordinates `json:"geo,omitempty"`
+}
+
+type jsonLdSchedule struct {
+	Type             string `json:"@type"`
+	RepeatFrequency  string `json:"repeatFrequency"`
+	ByDay            string `json:"byDay"`
+	StartTime        string `json:"startTime"`
+	ScheduleTimezone string `json:"scheduleTimezone"`
+	StartDate        string `json:"startDate,omitempty"`
+}
+
+type jsonLdOrganization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type jsonLdSportsEvent struct {
+	Context             string             `json:"@context"`
+	Type                string             `json:"@type"`
+	Name                string             `json:"name"`
+	Description         string             `json:"description"`
+	Url                 string             `json:"url"`
+	StartDate           string             `json:"startDate,omitempty"`
+	EventStatus         string             `json:"eventStatus"`
+	EventAttendanceMode string             `json:"eventAttendanceMode"`
+	IsAccessibleForFree bool               `json:"isAccessibleForFree"`
+	EventSchedule       jsonLdSchedule     `json:"eventSchedule"`
+	Location            jsonLdPlace        `json:"location"`
+	Organizer           jsonLdOrganization `json:"organizer"`
+}
+
+type jsonLdListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Url      string `json:"url"`
+	Name     string `json:"name"`
+}
+
+type jsonLdItemList struct {
+	Context         string           `json:"@context"`
+	Type            string           `json:"@type"`
+	Name            string           `json:"name"`
+	NumberOfItems   int              `json:"numberOfItems"`
+	ItemListElement []jsonLdListItem `json:"itemListElement"`
+}
+
+// JsonLdStatus maps the event's status to a schema.org EventStatusType.
+func (event Event) JsonLdStatus() string {
+	if event.Active() || event.Planned() {
+		return "https://schema.org/EventScheduled"
+	}
+	if event.TemporarilyClosed() {
+		return "https://schema.org/EventPostponed"
+	}
+	return "https://schema.org/EventCancelled"
+}
+
+// firstIso returns the date of the first run as YYYY-MM-DD, or "" if unknown.
+func (event Event) firstIso() string {
+	if m := reDateDDMMYYYY.FindStringSubmatch(event.First()); m != nil {
+		return fmt.Sprintf("%s-%s-%s", m[3], m[2], m[1])
+	}
+	return ""
+}
+
+// JsonLd returns the schema.org SportsEvent description of the event as JSON-LD; pageUrl is the canonical URL of the event's detail page.
+func (event Event) JsonLd(pageUrl string) (template.JS, error) {
+	place := jsonLdPlace{
+		Type: "Place",
+		Name: event.FixedLocation(),
+		Address: jsonLdPostalAddress{
+			Type:            "PostalAddress",
+			AddressLocality: event.FixedLocation(),
+			AddressRegion:   event.State(),
+			AddressCountry:  "DE",
+		},
+	}
+	if event.SpecificLocation != "" {
+		place.Name = event.SpecificLocation
+	}
+	if event.Coords.IsValid() {
+		place.Geo = &jsonLdGeoCoordinates{Type: "GeoCoordinates", Latitude: event.Coords.Lat, Longitude: event.Coords.Lon}
+	}
+
+	first := event.firstIso()
+	ld := jsonLdSportsEvent{
+		Context:             "https://schema.org",
+		Type:                "SportsEvent",
+		Name:                event.FixedName(),
+		Description:         fmt.Sprintf("Kostenloser, wöchentlicher 5km-Lauf in %s", event.FixedLocation()),
+		Url:                 pageUrl,
+		StartDate:           first,
+		EventStatus:         event.JsonLdStatus(),
+		EventAttendanceMode: "https://schema.org/OfflineEventAttendanceMode",
+		IsAccessibleForFree: true,
+		EventSchedule: jsonLdSchedule{
+			Type:             "Schedule",
+			RepeatFrequency:  "P1W",
+			ByDay:            "https://schema.org/Saturday",
+			StartTime:        "09:00",
+			ScheduleTimezone: "Europe/Berlin",
+			StartDate:        first,
+		},
+		Location: place,
+		Organizer: jsonLdOrganization{
+			Type: "Organization",
+			Name: event.FixedName(),
+			Url:  event.Url(),
+		},
+	}
+
+	buf, err := json.Marshal(ld)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(buf), nil
+}
+
+// EventListJsonLd returns a schema.org ItemList of all events as JSON-LD; pageUrl maps an event to the canonical URL of its detail page.
+func EventListJsonLd(events []*Event, pageUrl func(*Event) string) (template.JS, error) {
+	list := jsonLdItemList{
+		Context:         "https://schema.org",
+		Type:            "ItemList",
+		Name:            "Alle parkrun Standorte in Deutschland",
+		NumberOfItems:   len(events),
+		ItemListElement: make([]jsonLdListItem, 0, len(events)),
+	}
+	for i, event := range events {
+		list.ItemListElement = append(list.ItemListElement, jsonLdListItem{
+			Type:     "ListItem",
+			Position: i + 1,
+			Url:      pageUrl(event),
+			Name:     event.FixedName(),
+		})
+	}
+
+	buf, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(buf), nil
+}
@@ -0,0 +1,54 @@
+package parkrun
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+func TestEventJsonLd(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     Status
+		wantStatus string
+	}{
+		{name: "active", status: "", wantStatus: "https://schema.org/EventScheduled"},
+		{name: "planned", status: "geplant", wantStatus: "https://schema.org/EventScheduled"},
+		{name: "temporarily closed", status: "temporär geschlossen", wantStatus: "https://schema.org/EventPostponed"},
+		{name: "archived", status: "archiviert", wantStatus: "https://schema.org/EventCancelled"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			event := Event{Id: "dietenbach", Name: "Dietenbach parkrun", Location: "Freiburg", CountryUrl: "www.parkrun.com.de", Coords: utils.Coordinates{Lat: 48.0, Lon: 7.8}, Status: tc.status}
+			js, err := event.JsonLd("https://parkruns.de/dietenbach")
+			if err != nil {
+				t.Fatalf("JsonLd() error = %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal([]byte(js), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v\ncontent:\n%s", err, js)
+			}
+			if got["@type"] != "SportsEvent" {
+				t.Fatalf("@type = %v, want SportsEvent", got["@type"])
+			}
+			if got["eventStatus"] != tc.wantStatus {
+				t.Fatalf("eventStatus = %v, want %s", got["eventStatus"], tc.wantStatus)
+			}
+			schedule := got["eventSchedule"].(map[string]any)
+			if schedule["byDay"] != "https://schema.org/Saturday" || schedule["startTime"] != "09:00" || schedule["scheduleTimezone"] != "Europe/Berlin" {
+				t.Fatalf("unexpected eventSchedule: %v", schedule)
+			}
+			geo := got["location"].(map[string]any)["geo"].(map[string]any)
+			if geo["latitude"] != 48.0 || geo["longitude"] != 7.8 {
+				t.Fatalf("unexpected geo: %v", geo)
+			}
+			if organizer := got["organizer"].(map[string]any); organizer["url"] != "https://www.parkrun.com.de/dietenbach" {
+				t.Fatalf("unexpected organizer url: %v", organizer["url"])
+			}
+		})
+	}
+}
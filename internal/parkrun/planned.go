@@ -0,0 +1,255 @@
+package parkrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+type DatePrecision int
+
+const (
+	PrecisionDay DatePrecision = iota
+	PrecisionMonth
+	PrecisionYear
+)
+
+// PlannedDate is a date of the planned sheet, which is often only known roughly (month or year).
+type PlannedDate struct {
+	Time      time.Time
+	Precision DatePrecision
+}
+
+var plannedDateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{"02.01.2006", PrecisionDay},
+	{"2006-01-02", PrecisionDay},
+	{"01.2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// ParsePlannedDate parses "DD.MM.YYYY", "YYYY-MM-DD", "MM.YYYY" or "YYYY"; empty values and "?" result in a zero date.
+func ParsePlannedDate(s string) (PlannedDate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "?" {
+		return PlannedDate{}, nil
+	}
+	for _, l := range plannedDateLayouts {
+		if t, err := time.Parse(l.layout, s); err == nil {
+			return PlannedDate{t, l.precision}, nil
+		}
+	}
+	return PlannedDate{}, fmt.Errorf("cannot parse date '%s'", s)
+}
+
+func (d PlannedDate) IsZero() bool {
+	return d.Time.IsZero()
+}
+
+var germanMonths = []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+
+func (d PlannedDate) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	switch d.Precision {
+	case PrecisionMonth:
+		return fmt.Sprintf("%s %d", germanMonths[d.Time.Month()-1], d.Time.Year())
+	case PrecisionYear:
+		return fmt.Sprintf("%d", d.Time.Year())
+	}
+	return d.Time.Format("02.01.2006")
+}
+
+func (d PlannedDate) Sortable() string {
+	if d.IsZero() {
+		return "9999-99-99"
+	}
+	return d.Time.Format("2006-01-02")
+}
+
+// normalizeName maps event names like "Südpark parkrun" and ids like "suedpark" to the same string.
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "parkrun", "").Replace(name)
+	var sb strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// FindEvent returns the event from events.json that matches the planned parkrun by name or id, or nil.
+func (p PlannedData) FindEvent(events []*Event) *Event {
+	name := normalizeName(p.Name)
+	if name == "" {
+		return nil
+	}
+	for _, event := range events {
+		if event.CountryUrl == "" {
+			// not (yet) in events.json
+			continue
+		}
+		if normalizeName(event.Name) == name || normalizeName(event.Id) == name {
+			return event
+		}
+	}
+	return nil
+}
+
+// PlannedEntry is a planned parkrun together with its event, if it already appeared in events.json.
+type PlannedEntry struct {
+	PlannedData
+	Event *Event
+}
+
+// PlannedTimeline combines the planned parkruns with their events; the entries are sorted by announcement date.
+func PlannedTimeline(planned []PlannedData, events []*Event) []*PlannedEntry {
+	entries := make([]*PlannedEntry, 0, len(planned))
+	for _, p := range planned {
+		entries = append(entries, &PlannedEntry{p, p.FindEvent(events)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Added.Sortable() < entries[j].Added.Sortable()
+	})
+	return entries
+}
+
+// PlannedRecord is the history of a planned parkrun, from its first appearance in the planned sheet to its appearance in events.json.
+type PlannedRecord struct {
+	Name      string `json:"name"`
+	City      string `json:"city"`
+	Added     string `json:"added,omitempty"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+	Launched  string `json:"launched,omitempty"`
+	EventId   string `json:"event_id,omitempty"`
+}
+
+func (r PlannedRecord) start() time.Time {
+	if t, err := time.Parse("2006-01-02", r.Added); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02", r.FirstSeen)
+	return t
+}
+
+// DaysInPlanning is the number of days from the announcement (or first appearance in the planned sheet) to the appearance in events.json.
+func (r PlannedRecord) DaysInPlanning() int {
+	launched, err := time.Parse("2006-01-02", r.Launched)
+	if err != nil {
+		return 0
+	}
+	return int(launched.Sub(r.start()).Hours() / 24)
+}
+
+func (r PlannedRecord) StartF() string {
+	return formatIsoDate(r.start().Format("2006-01-02"))
+}
+
+func (r PlannedRecord) LaunchedF() string {
+	return formatIsoDate(r.Launched)
+}
+
+func formatIsoDate(s string) string {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Format("02.01.2006")
+	}
+	return s
+}
+
+// PlannedHistory records how long the planned parkruns spend in planning; it's persisted between builds.
+type PlannedHistory struct {
+	Records []*PlannedRecord `json:"records"`
+}
+
+// LoadPlannedHistory reads the history from a JSON file; a missing file results in an empty history.
+func LoadPlannedHistory(filePath string) (*PlannedHistory, error) {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &PlannedHistory{}, nil
+		}
+		return nil, fmt.Errorf("while reading planned history %s: %w", filePath, err)
+	}
+
+	history := &PlannedHistory{}
+	if err := json.Unmarshal(buf, history); err != nil {
+		return nil, fmt.Errorf("while parsing planned history %s: %w", filePath, err)
+	}
+	return history, nil
+}
+
+func (history PlannedHistory) Save(filePath string) error {
+	buf, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(filePath, buf)
+}
+
+func (history *PlannedHistory) find(name string) *PlannedRecord {
+	for _, r := range history.Records {
+		if normalizeName(r.Name) == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Update adds the current planned parkruns to the history and marks the ones that appeared in events.json as launched.
+// It returns the records that have been launched with this update.
+// An empty history is seeded: planned parkruns that are already in events.json are recorded without launch date, since
+// it's unknown when they appeared.
+func (history *PlannedHistory) Update(entries []*PlannedEntry, now time.Time) []*PlannedRecord {
+	today := now.Format("2006-01-02")
+	seeding := len(history.Records) == 0
+	launched := make([]*PlannedRecord, 0)
+	for _, entry := range entries {
+		name := normalizeName(entry.Name)
+		if name == "" {
+			continue
+		}
+		record := history.find(name)
+		if record == nil {
+			record = &PlannedRecord{Name: entry.Name, City: entry.City, FirstSeen: today}
+			history.Records = append(history.Records, record)
+		}
+		record.LastSeen = today
+		if entry.Added.Precision == PrecisionDay && !entry.Added.IsZero() {
+			record.Added = entry.Added.Time.Format("2006-01-02")
+		}
+		if entry.Event != nil && record.EventId == "" {
+			record.EventId = entry.Event.Id
+			if !seeding {
+				record.Launched = today
+				launched = append(launched, record)
+			}
+		}
+	}
+	return launched
+}
+
+// LaunchedRecords returns the records of the planned parkruns that appeared in events.json, latest first.
+func (history PlannedHistory) LaunchedRecords() []*PlannedRecord {
+	records := make([]*PlannedRecord, 0)
+	for _, r := range history.Records {
+		if r.Launched != "" {
+			records = append(records, r)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Launched > records[j].Launched
+	})
+	return records
+}
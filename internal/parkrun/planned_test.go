@@ -0,0 +1,86 @@
+package parkrun
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePlannedDate(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "?", want: ""},
+		{input: "06.06.2026", want: "06.06.2026"},
+		{input: "2026-06-06", want: "06.06.2026"},
+		{input: "03.2026", want: "März 2026"},
+		{input: "2027", want: "2027"},
+		{input: "Frühjahr", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParsePlannedDate(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePlannedDate(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if got.String() != tc.want {
+				t.Fatalf("ParsePlannedDate(%q) = %q, want %q", tc.input, got.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestPlannedHistory(t *testing.T) {
+	added, _ := ParsePlannedDate("01.03.2026")
+	planned := []PlannedData{
+		{Name: "Südpark parkrun", City: "Musterstadt", Added: added},
+		{Name: "Neuer Park parkrun", City: "Neustadt"},
+	}
+	events := []*Event{
+		{Id: "suedpark", Name: "Südpark parkrun", CountryUrl: "www.parkrun.com.de"},
+		{Id: "neuerpark", Name: "Neuer Park parkrun"}, // only in the data sheet, not in events.json
+	}
+
+	timeline := PlannedTimeline(planned, events)
+	if timeline[0].Name != "Südpark parkrun" || timeline[0].Event == nil || timeline[0].Event.Id != "suedpark" {
+		t.Fatalf("unexpected first entry: %+v", timeline[0])
+	}
+	if timeline[1].Event != nil {
+		t.Fatalf("unexpected event for '%s': %+v", timeline[1].Name, timeline[1].Event)
+	}
+
+	// the first update seeds the history: the already listed event is recorded without launch date
+	seeded := &PlannedHistory{}
+	if launched := seeded.Update(timeline, time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC)); len(launched) != 0 {
+		t.Fatalf("unexpected launched records when seeding: %+v", launched)
+	}
+	if records := seeded.LaunchedRecords(); len(records) != 0 || seeded.Records[0].EventId != "suedpark" {
+		t.Fatalf("unexpected seeded records: %+v", seeded.Records)
+	}
+	if launched := seeded.Update(timeline, time.Date(2026, 6, 6, 12, 0, 0, 0, time.UTC)); len(launched) != 0 {
+		t.Fatalf("unexpected launched records after seeding: %+v", launched)
+	}
+
+	// the event appears in events.json after the history has been started
+	history := &PlannedHistory{}
+	history.Update(PlannedTimeline(planned, nil), time.Date(2026, 5, 23, 12, 0, 0, 0, time.UTC))
+	launched := history.Update(timeline, time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC))
+	if len(launched) != 1 || launched[0].EventId != "suedpark" || launched[0].DaysInPlanning() != 90 {
+		t.Fatalf("unexpected launched records: %+v", launched)
+	}
+	if len(history.Records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(history.Records))
+	}
+
+	// a later update must not launch the event again
+	if launched := history.Update(timeline, time.Date(2026, 6, 6, 12, 0, 0, 0, time.UTC)); len(launched) != 0 {
+		t.Fatalf("unexpected launched records: %+v", launched)
+	}
+	if records := history.LaunchedRecords(); len(records) != 1 || records[0].Launched != "2026-05-30" {
+		t.Fatalf("unexpected launched records: %+v", records)
+	}
+}
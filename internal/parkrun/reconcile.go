@@ -0,0 +1,165 @@
+package parkrun
+
+import (
+	"fmt"
+	"sort"
+
+	parkrunparser "github.com/flopp/go-parkrunparser"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// DefaultMoveThreshold is the distance (in meters) from which a change of the coordinates in events.json counts as a move.
+const DefaultMoveThreshold = 100.0
+
+// EventsJsonEntry is the relevant part of an event of parkrun's events.json.
+type EventsJsonEntry struct {
+	Id       string  `json:"id"`
+	LongName string  `json:"long_name"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+func (e EventsJsonEntry) coords() utils.Coordinates {
+	return utils.Coordinates{Lat: e.Lat, Lon: e.Lon}
+}
+
+// LoadEventsJson returns the events of events.json (optionally only the German ones), sorted by ID.
+func LoadEventsJson(events_json_file string, germanyOnly bool) ([]EventsJsonEntry, error) {
+	buf, err := utils.ReadFile(events_json_file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", events_json_file, err)
+	}
+
+	eventsJson, err := parkrunparser.ParseEvents(buf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", events_json_file, err)
+	}
+
+	entries := make([]EventsJsonEntry, 0)
+	for _, e := range eventsJson.Events {
+		if germanyOnly && e.Country.Name() != "Germany" {
+			continue
+		}
+		entries = append(entries, EventsJsonEntry{e.Name, e.LongName, e.Coordinates.Lat, e.Coordinates.Lng})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+	return entries, nil
+}
+
+// EventRename is an event of events.json whose ID or long name changed.
+type EventRename struct {
+	OldId, NewId             string
+	OldLongName, NewLongName string
+}
+
+// EventMove is an event of events.json whose coordinates moved.
+type EventMove struct {
+	Id             string
+	LongName       string
+	From, To       utils.Coordinates
+	DistanceMeters float64
+}
+
+// Reconciliation lists the differences between events.json and the data source, and between two versions of events.json.
+type Reconciliation struct {
+	MissingInEventsJson []*ParkrunInfo    // data source rows without event in events.json (they become planned events)
+	MissingInSource     []EventsJsonEntry // events.json events without data source row (no state, city, route)
+	New                 []EventsJsonEntry // events that are new in events.json (except renamed events)
+	Removed             []EventsJsonEntry // events that have been removed from events.json (except renamed events)
+	Renamed             []EventRename     // changed ID (same coordinates) or long name
+	Moved               []EventMove       // coordinates moved by more than the threshold
+}
+
+// Empty returns true if there are no differences.
+func (r Reconciliation) Empty() bool {
+	return len(r.MissingInEventsJson) == 0 && len(r.MissingInSource) == 0 && len(r.New) == 0 && len(r.Removed) == 0 && len(r.Renamed) == 0 && len(r.Moved) == 0
+}
+
+// Reconcile compares the current events.json with the data source (infos) and with the previous version of events.json
+// (prev; nil to skip the comparison). An event that disappeared while a new event appeared at (almost) the same place
+// (closer than moveThreshold) counts as renamed.
+func Reconcile(prev, cur []EventsJsonEntry, infos map[string]*ParkrunInfo, moveThreshold float64) *Reconciliation {
+	r := &Reconciliation{}
+
+	curById := make(map[string]EventsJsonEntry)
+	for _, e := range cur {
+		curById[e.Id] = e
+	}
+
+	// events.json vs. data source
+	for _, e := range cur {
+		if _, found := infos[e.Id]; !found {
+			r.MissingInSource = append(r.MissingInSource, e)
+		}
+	}
+	ids := make([]string, 0, len(infos))
+	for id := range infos {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, found := curById[id]; !found {
+			r.MissingInEventsJson = append(r.MissingInEventsJson, infos[id])
+		}
+	}
+
+	if prev == nil {
+		return r
+	}
+
+	// previous vs. current events.json
+	prevById := make(map[string]EventsJsonEntry)
+	for _, e := range prev {
+		prevById[e.Id] = e
+	}
+	var added, removed []EventsJsonEntry
+	for _, e := range cur {
+		p, found := prevById[e.Id]
+		if !found {
+			added = append(added, e)
+			continue
+		}
+		if p.LongName != e.LongName {
+			r.Renamed = append(r.Renamed, EventRename{p.Id, e.Id, p.LongName, e.LongName})
+		}
+		if distance := utils.DistanceMeters(p.coords(), e.coords()); distance > moveThreshold {
+			r.Moved = append(r.Moved, EventMove{e.Id, e.LongName, p.coords(), e.coords(), distance})
+		}
+	}
+	for _, p := range prev {
+		if _, found := curById[p.Id]; !found {
+			removed = append(removed, p)
+		}
+	}
+
+	// a removed and an added event at the same place are a renamed event
+	renamedTo := make(map[string]bool)
+	for _, p := range removed {
+		var match *EventsJsonEntry
+		for i, e := range added {
+			if !renamedTo[e.Id] && utils.DistanceMeters(p.coords(), e.coords()) <= moveThreshold {
+				match = &added[i]
+				break
+			}
+		}
+		if match == nil {
+			r.Removed = append(r.Removed, p)
+			continue
+		}
+		renamedTo[match.Id] = true
+		r.Renamed = append(r.Renamed, EventRename{p.Id, match.Id, p.LongName, match.LongName})
+	}
+	for _, e := range added {
+		if !renamedTo[e.Id] {
+			r.New = append(r.New, e)
+		}
+	}
+	sort.SliceStable(r.Renamed, func(i, j int) bool {
+		return r.Renamed[i].NewId < r.Renamed[j].NewId
+	})
+
+	return r
+}
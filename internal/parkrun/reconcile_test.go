@@ -0,0 +1,74 @@
+package parkrun
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	infos := map[string]*ParkrunInfo{
+		"dietenbach":       {Id: "dietenbach", Name: "Dietenbach parkrun"},
+		"seepark-freiburg": {Id: "seepark-freiburg", Name: "Seepark parkrun"},
+		"neuerpark":        {Id: "neuerpark", Name: "Neuer Park parkrun", Status: StatusPlanned},
+		"kurpark":          {Id: "kurpark", Name: "Kurpark parkrun", Status: StatusArchived},
+	}
+	prev := []EventsJsonEntry{
+		{Id: "dietenbach", LongName: "Dietenbach parkrun", Lat: 47.99, Lon: 7.79},
+		{Id: "kurpark", LongName: "Kurpark parkrun", Lat: 50.0, Lon: 8.0},
+		{Id: "seepark", LongName: "Seepark parkrun", Lat: 48.01, Lon: 7.82},
+	}
+	cur := []EventsJsonEntry{
+		{Id: "dietenbach", LongName: "Dietenbachsee parkrun", Lat: 47.995, Lon: 7.79},
+		{Id: "hasenheide", LongName: "Hasenheide parkrun", Lat: 52.48, Lon: 13.41},
+		{Id: "seepark-freiburg", LongName: "Seepark parkrun", Lat: 48.0101, Lon: 7.8201},
+	}
+
+	r := Reconcile(prev, cur, infos, DefaultMoveThreshold)
+
+	ids := func(entries []EventsJsonEntry) []string {
+		result := make([]string, 0, len(entries))
+		for _, e := range entries {
+			result = append(result, e.Id)
+		}
+		return result
+	}
+	testCases := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"missing in source", ids(r.MissingInSource), []string{"hasenheide"}},
+		{"new", ids(r.New), []string{"hasenheide"}},
+		{"removed", ids(r.Removed), []string{"kurpark"}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if strings.Join(tc.got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("got %v, want %v", tc.got, tc.want)
+			}
+		})
+	}
+
+	if len(r.MissingInEventsJson) != 2 || r.MissingInEventsJson[0].Id != "kurpark" || r.MissingInEventsJson[1].Id != "neuerpark" {
+		t.Fatalf("MissingInEventsJson = %v", r.MissingInEventsJson)
+	}
+	wantRenamed := []EventRename{
+		{"dietenbach", "dietenbach", "Dietenbach parkrun", "Dietenbachsee parkrun"},
+		{"seepark", "seepark-freiburg", "Seepark parkrun", "Seepark parkrun"},
+	}
+	if len(r.Renamed) != len(wantRenamed) || r.Renamed[0] != wantRenamed[0] || r.Renamed[1] != wantRenamed[1] {
+		t.Fatalf("Renamed = %+v, want %+v", r.Renamed, wantRenamed)
+	}
+	if len(r.Moved) != 1 || r.Moved[0].Id != "dietenbach" || r.Moved[0].DistanceMeters < 500 || r.Moved[0].DistanceMeters > 600 {
+		t.Fatalf("Moved = %+v", r.Moved)
+	}
+
+	// without previous events.json only the data source is compared
+	if r := Reconcile(nil, cur, infos, DefaultMoveThreshold); len(r.New) != 0 || len(r.Renamed) != 0 || len(r.MissingInSource) != 1 {
+		t.Fatalf("Reconcile() without previous events.json = %+v", r)
+	}
+	if r := Reconcile(cur, cur, map[string]*ParkrunInfo{"dietenbach": {}, "hasenheide": {}, "seepark-freiburg": {}}, DefaultMoveThreshold); !r.Empty() {
+		t.Fatalf("Reconcile() of identical data = %+v", r)
+	}
+}
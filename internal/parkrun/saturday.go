@@ -0,0 +1,139 @@
+package parkrun
+
+import (
+	"time"
+)
+
+// NextRunDay returns the next Saturday or special day; the current day counts until one hour after the start (the runs are over afterwards).
+func (days *SpecialDays) NextRunDay(now time.Time) time.Time {
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if start, ok := days.RunDayStartTime(now); ok && !now.Before(start.Add(time.Hour)) {
+		date = date.AddDate(0, 0, 1)
+	}
+	for !days.IsRunDay(date) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// EventDayStatus tells whether an event takes place on a specific day, and lists nearby alternatives if not.
+type EventDayStatus struct {
+	Event        *Event
+	Running      bool
+	Unknown      bool // special day without a list of events: the event might run
+	FirstRun     bool
+	Start        string
+	Reason       string
+	Alternatives []*EventDistance
+}
+
+// RunDay is the status of all active (or starting) events on a Saturday or special day.
+type RunDay struct {
+	Date    time.Time
+	Special string // name of the special day, "" for regular Saturdays
+	Events  []*EventDayStatus
+}
+
+func (day RunDay) DateF() string {
+	return day.Date.Format("02.01.2006")
+}
+
+func (day RunDay) Running() int {
+	count := 0
+	for _, s := range day.Events {
+		if s.Running {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (day RunDay) NotRunning() int {
+	count := 0
+	for _, s := range day.Events {
+		if !s.Running && !s.Unknown {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (day RunDay) Unknown() int {
+	return len(day.Events) - day.Running() - day.NotRunning()
+}
+
+// runningOn returns the status of the event on the given date (without alternatives).
+func (event *Event) runningOn(days *SpecialDays, date time.Time) *EventDayStatus {
+	status := &EventDayStatus{Event: event, Start: defaultStartTime}
+	for _, c := range event.Cancellations {
+		if sameDay(c.Date, date) {
+			status.Reason = "Absage: " + c.ReasonGerman()
+			return status
+		}
+	}
+	switch {
+	case event.Active():
+		status.Running = true
+	case event.Planned():
+		if first, ok := event.firstRunDate(); ok && sameDay(first, date) {
+			status.Running = true
+			status.FirstRun = true
+		} else {
+			status.Reason = "geplant"
+		}
+	case event.TemporarilyClosed():
+		status.Reason = "temporär geschlossen"
+	default:
+		status.Reason = "archiviert"
+	}
+
+	if day := days.On(date); day != nil && status.Running {
+		start, runs := day.StartOn(event.Id, date)
+		switch {
+		case !day.KnownEvents() && date.Weekday() != time.Saturday:
+			status.Running = false
+			status.Unknown = true
+			status.Reason = "unbekannt"
+		case !runs:
+			status.Running = false
+			status.Reason = "kein Lauf an " + day.Name
+		}
+		status.Start = start
+	}
+	return status
+}
+
+// NewRunDay determines for all active, temporarily closed and starting events whether they take place on the given date.
+// Cancelled events get up to three running nearby events as alternatives (requires PopulateNearby).
+func NewRunDay(days *SpecialDays, events []*Event, date time.Time) *RunDay {
+	day := &RunDay{Date: date}
+	if special := days.On(date); special != nil {
+		day.Special = special.Name
+	}
+	running := make(map[*Event]bool)
+	for _, event := range events {
+		if !event.Active() && !event.Planned() && !event.TemporarilyClosed() {
+			continue
+		}
+		status := event.runningOn(days, date)
+		if event.Planned() && !status.FirstRun {
+			// planned events are only interesting on the day of their first run
+			continue
+		}
+		running[event] = status.Running
+		day.Events = append(day.Events, status)
+	}
+
+	for _, status := range day.Events {
+		if status.Running || status.Unknown {
+			continue
+		}
+		for _, nearby := range status.Event.NearbyEvents {
+			if running[nearby.Event] && len(status.Alternatives) < 3 {
+				status.Alternatives = append(status.Alternatives, nearby)
+			}
+		}
+	}
+
+	return day
+}
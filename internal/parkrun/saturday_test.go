@@ -0,0 +1,83 @@
+package parkrun
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRunDay(t *testing.T) {
+	testCases := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{name: "wednesday", now: time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC), want: "2026-06-06"},
+		{name: "saturday morning", now: time.Date(2026, 6, 6, 8, 30, 0, 0, time.UTC), want: "2026-06-06"},
+		{name: "saturday noon", now: time.Date(2026, 6, 6, 12, 0, 0, 0, time.UTC), want: "2026-06-13"},
+		{name: "before October 3rd", now: time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC), want: "2026-10-03"},
+		{name: "New Year's Day (Friday)", now: time.Date(2026, 12, 30, 12, 0, 0, 0, time.UTC), want: "2027-01-01"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DefaultSpecialDays().NextRunDay(tc.now).Format("2006-01-02"); got != tc.want {
+				t.Fatalf("NextRunDay(%v) = %s, want %s", tc.now, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewRunDay(t *testing.T) {
+	date := time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC)
+	running := &Event{Id: "dietenbach", Name: "Dietenbach parkrun"}
+	cancelled := &Event{Id: "fuldaaue", Name: "Fuldaaue parkrun", Cancellations: []Cancellation{{Date: date, Reason: ReasonCourseUnsafe}}}
+	closed := &Event{Id: "kurpark", Name: "Kurpark parkrun", Status: StatusTemporarilyClosed}
+	archived := &Event{Id: "alt", Name: "Alt parkrun", Status: StatusArchived}
+	planned := &Event{Id: "neu", Name: "Neu parkrun", Status: StatusPlanned}
+	cancelled.NearbyEvents = []*EventDistance{{Event: closed, DistanceKM: 5}, {Event: running, DistanceKM: 10}}
+
+	day := NewRunDay(DefaultSpecialDays(), []*Event{running, cancelled, closed, archived, planned}, date)
+
+	if day.Special != "" || len(day.Events) != 3 || day.Running() != 1 || day.NotRunning() != 2 {
+		t.Fatalf("unexpected run day: %+v", day)
+	}
+	status := day.Events[1]
+	if status.Event != cancelled || status.Running || status.Reason != "Absage: Strecke unsicher oder gesperrt" {
+		t.Fatalf("unexpected status of cancelled event: %+v", status)
+	}
+	if len(status.Alternatives) != 1 || status.Alternatives[0].Event != running {
+		t.Fatalf("unexpected alternatives: %+v", status.Alternatives)
+	}
+	if day.Events[2].Running || day.Events[2].Reason != "temporär geschlossen" {
+		t.Fatalf("unexpected status of closed event: %+v", day.Events[2])
+	}
+}
+
+func TestNewRunDaySpecialDay(t *testing.T) {
+	// Christmas 2026 is a Friday; New Year's Day 2027, too
+	days := &SpecialDays{Days: []*SpecialDay{
+		{Date: "12-25", Name: "Weihnachten"},
+		{Date: "01-01", Name: "Neujahr", Events: []SpecialDayEvent{{Id: "seepark", Start: "10:00"}}},
+	}}
+	dietenbach := &Event{Id: "dietenbach", Name: "Dietenbach parkrun"}
+	seepark := &Event{Id: "seepark", Name: "Seepark parkrun"}
+
+	testCases := []struct {
+		date        time.Time
+		wantRunning int
+		wantUnknown int
+	}{
+		{time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC), 0, 2},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), 1, 0},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.date.Format("2006-01-02"), func(t *testing.T) {
+			day := NewRunDay(days, []*Event{dietenbach, seepark}, tc.date)
+			if day.Special == "" || day.Running() != tc.wantRunning || day.Unknown() != tc.wantUnknown {
+				t.Fatalf("unexpected run day: special=%q running=%d unknown=%d", day.Special, day.Running(), day.Unknown())
+			}
+		})
+	}
+}
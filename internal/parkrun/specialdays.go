@@ -0,0 +1,234 @@
+package parkrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+const defaultStartTime = "09:00"
+
+// SpecialDayEvent is an event that holds an extra run on a special day (with an optional start time).
+type SpecialDayEvent struct {
+	Id    string `json:"id"`
+	Start string `json:"start,omitempty"`
+}
+
+// SpecialDay is a holiday with extra parkruns. The date is either recurring ("MM-DD") or a specific day ("YYYY-MM-DD").
+// If Events is nil, it's unknown which events hold extra runs; otherwise only the listed events do. On Saturdays, the
+// regular runs of the other events take place as usual.
+type SpecialDay struct {
+	Date   string            `json:"date"`
+	Name   string            `json:"name"`
+	Start  string            `json:"start,omitempty"`
+	Events []SpecialDayEvent `json:"events,omitempty"`
+}
+
+type SpecialDays struct {
+	Days []*SpecialDay `json:"days"`
+}
+
+// DefaultSpecialDays returns the built-in calendar, used if there's no special days file.
+func DefaultSpecialDays() *SpecialDays {
+	return &SpecialDays{Days: []*SpecialDay{
+		{Date: "12-25", Name: "Weihnachten"},
+		{Date: "01-01", Name: "Neujahr"},
+		{Date: "10-03", Name: "Tag der Deutschen Einheit"},
+	}}
+}
+
+// LoadSpecialDays reads and validates a special days calendar from a JSON file.
+func LoadSpecialDays(filePath string) (*SpecialDays, error) {
+	buf, err := utils.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	days := &SpecialDays{}
+	if err := json.Unmarshal(buf, days); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filePath, err)
+	}
+	for _, day := range days.Days {
+		if err := day.validate(); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", filePath, err)
+		}
+	}
+	return days, nil
+}
+
+func validateStartTime(start string) error {
+	if start == "" {
+		return nil
+	}
+	if _, err := time.Parse("15:04", start); err != nil {
+		return fmt.Errorf("invalid start time '%s'", start)
+	}
+	return nil
+}
+
+func (day SpecialDay) validate() error {
+	if _, err := time.Parse("01-02", day.Date); err != nil {
+		if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+			return fmt.Errorf("invalid date '%s' of special day '%s'", day.Date, day.Name)
+		}
+	}
+	if err := validateStartTime(day.Start); err != nil {
+		return fmt.Errorf("special day '%s': %w", day.Name, err)
+	}
+	for _, e := range day.Events {
+		if err := validateStartTime(e.Start); err != nil {
+			return fmt.Errorf("special day '%s', event '%s': %w", day.Name, e.Id, err)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the special day falls on the given date.
+func (day SpecialDay) Matches(date time.Time) bool {
+	if strings.Count(day.Date, "-") == 1 {
+		return date.Format("01-02") == day.Date
+	}
+	return date.Format("2006-01-02") == day.Date
+}
+
+// DefaultStart is the start time of the special day ("09:00" if not specified).
+func (day SpecialDay) DefaultStart() string {
+	if day.Start != "" {
+		return day.Start
+	}
+	return defaultStartTime
+}
+
+// KnownEvents returns true if the special day lists the events that run.
+func (day SpecialDay) KnownEvents() bool {
+	return day.Events != nil
+}
+
+// EventStart returns the start time of the event on the special day, and whether the event runs;
+// if the events of the day are unknown, every event might run at the default time.
+func (day SpecialDay) EventStart(eventId string) (string, bool) {
+	if !day.KnownEvents() {
+		return day.DefaultStart(), true
+	}
+	for _, e := range day.Events {
+		if e.Id == eventId {
+			if e.Start != "" {
+				return e.Start, true
+			}
+			return day.DefaultStart(), true
+		}
+	}
+	return "", false
+}
+
+// StartOn returns the start time of the event on the special day at the given date, and whether the event runs; unlike
+// EventStart, it takes the regular Saturday runs of events that are not listed into account.
+func (day SpecialDay) StartOn(eventId string, date time.Time) (string, bool) {
+	start, runs := day.EventStart(eventId)
+	if !runs && date.Weekday() == time.Saturday {
+		return defaultStartTime, true
+	}
+	return start, runs
+}
+
+// On returns the special day on the given date, or nil; a nil calendar has no special days.
+func (days *SpecialDays) On(date time.Time) *SpecialDay {
+	if days == nil {
+		return nil
+	}
+	for _, day := range days.Days {
+		if day.Matches(date) {
+			return day
+		}
+	}
+	return nil
+}
+
+// IsRunDay returns true for Saturdays and special days.
+func (days *SpecialDays) IsRunDay(date time.Time) bool {
+	return date.Weekday() == time.Saturday || days.On(date) != nil
+}
+
+// RunDayStartTime returns the (default) start time on the day of 'now'; ok is false if the day is no run day.
+func (days *SpecialDays) RunDayStartTime(now time.Time) (time.Time, bool) {
+	if !days.IsRunDay(now) {
+		return time.Time{}, false
+	}
+	start := defaultStartTime
+	if day := days.On(now); day != nil {
+		start = day.DefaultStart()
+	}
+	t, _ := time.Parse("15:04", start)
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), true
+}
+
+// UnknownEvents returns the ids listed in the special days calendar that don't match any event.
+func (days *SpecialDays) UnknownEvents(events []*Event) []string {
+	known := make(map[string]struct{})
+	for _, event := range events {
+		known[event.Id] = struct{}{}
+	}
+	unknown := make([]string, 0)
+	if days == nil {
+		return unknown
+	}
+	for _, day := range days.Days {
+		for _, e := range day.Events {
+			if _, found := known[e.Id]; !found {
+				unknown = append(unknown, fmt.Sprintf("%s (%s)", e.Id, day.Name))
+			}
+		}
+	}
+	return unknown
+}
+
+// RunsOn returns true if a run of the event is expected on the given date: on Saturdays and special days,
+// unless the special day (not on a Saturday) lists the events that run and the event is not among them.
+func (event Event) RunsOn(days *SpecialDays, date time.Time) bool {
+	if day := days.On(date); day != nil {
+		_, runs := day.StartOn(event.Id, date)
+		return runs
+	}
+	return date.Weekday() == time.Saturday
+}
+
+// SpecialRun is a special day and the event's start time on that day.
+type SpecialRun struct {
+	Date  time.Time
+	Day   *SpecialDay
+	Start string
+	Runs  bool // false if the day lists the events that run, and the event is neither among them nor runs anyway (Saturday)
+}
+
+func (run SpecialRun) DateF() string {
+	return run.Date.Format("02.01.2006")
+}
+
+// SpecialRuns returns the special days in [from, until), not before the event's first run ('first' may be zero).
+func (event Event) SpecialRuns(days *SpecialDays, from time.Time, until time.Time, first time.Time) []SpecialRun {
+	runs := make([]SpecialRun, 0)
+	for date := from; date.Before(until); date = date.AddDate(0, 0, 1) {
+		if date.Before(first) {
+			continue
+		}
+		if day := days.On(date); day != nil {
+			start, ok := day.StartOn(event.Id, date)
+			runs = append(runs, SpecialRun{date, day, start, ok})
+		}
+	}
+	return runs
+}
+
+// UpcomingSpecialRuns returns the special days with (possible) runs of the event within the next 90 days.
+func (event Event) UpcomingSpecialRuns(days *SpecialDays, now time.Time) []SpecialRun {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	runs := make([]SpecialRun, 0)
+	for _, run := range event.SpecialRuns(days, today, today.AddDate(0, 0, 90), time.Time{}) {
+		if run.Runs {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
@@ -0,0 +1,115 @@
+package parkrun
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSpecialDays(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `{"days": [{"date": "12-25", "name": "Weihnachten"}, {"date": "2026-04-06", "name": "Ostermontag", "start": "10:00", "events": [{"id": "dietenbach", "start": "09:30"}]}]}`},
+		{name: "bad date", content: `{"days": [{"date": "25.12.", "name": "Weihnachten"}]}`, wantErr: true},
+		{name: "bad start", content: `{"days": [{"date": "12-25", "name": "Weihnachten", "start": "9 Uhr"}]}`, wantErr: true},
+		{name: "bad event start", content: `{"days": [{"date": "12-25", "name": "Weihnachten", "events": [{"id": "dietenbach", "start": "25:00"}]}]}`, wantErr: true},
+		{name: "bad json", content: `{"days": [`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "specialdays.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadSpecialDays(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadSpecialDays() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpecialDays(t *testing.T) {
+	days := &SpecialDays{Days: []*SpecialDay{
+		{Date: "12-25", Name: "Weihnachten"},
+		{Date: "2026-04-06", Name: "Ostermontag", Start: "10:00", Events: []SpecialDayEvent{{Id: "dietenbach"}, {Id: "seepark", Start: "09:30"}}},
+		{Date: "2026-10-03", Name: "Tag der Deutschen Einheit", Events: []SpecialDayEvent{{Id: "seepark"}}},
+	}}
+
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	testCases := []struct {
+		date      string
+		event     string
+		wantRuns  bool
+		wantStart string
+	}{
+		{date: "2026-12-25", event: "dietenbach", wantRuns: true, wantStart: "09:00"},
+		{date: "2027-12-25", event: "dietenbach", wantRuns: true, wantStart: "09:00"},
+		{date: "2026-04-06", event: "dietenbach", wantRuns: true, wantStart: "10:00"},
+		{date: "2026-04-06", event: "seepark", wantRuns: true, wantStart: "09:30"},
+		{date: "2026-04-06", event: "other", wantRuns: false},
+		{date: "2027-04-06", event: "dietenbach", wantRuns: false},
+		{date: "2026-10-03", event: "seepark", wantRuns: true, wantStart: "09:00"},
+		{date: "2026-10-03", event: "dietenbach", wantRuns: true, wantStart: "09:00"}, // Saturday: regular run
+		{date: "2026-10-10", event: "dietenbach", wantRuns: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.date+"/"+tc.event, func(t *testing.T) {
+			event := Event{Id: tc.event}
+			d := date(tc.date)
+			if got := event.RunsOn(days, d); got != tc.wantRuns {
+				t.Fatalf("RunsOn(%s) = %v, want %v", tc.date, got, tc.wantRuns)
+			}
+			if day := days.On(d); day != nil {
+				if start, _ := day.StartOn(tc.event, d); start != tc.wantStart {
+					t.Fatalf("StartOn(%s) = %q, want %q", tc.event, start, tc.wantStart)
+				}
+			}
+		})
+	}
+}
+
+func TestRunDayStartTime(t *testing.T) {
+	days := &SpecialDays{Days: []*SpecialDay{
+		{Date: "12-25", Name: "Weihnachten"},
+		{Date: "12-31", Name: "Silvester", Start: "11:00"},
+	}}
+
+	testCases := []struct {
+		now     string
+		want    string
+		wantRun bool
+	}{
+		{now: "2026-10-17 07:00", want: "2026-10-17 09:00", wantRun: true},
+		{now: "2026-10-18 07:00", wantRun: false},
+		{now: "2026-12-25 12:00", want: "2026-12-25 09:00", wantRun: true},
+		{now: "2026-12-31 08:00", want: "2026-12-31 11:00", wantRun: true},
+		{now: "2026-10-05 08:00", wantRun: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.now, func(t *testing.T) {
+			now, _ := time.Parse("2006-01-02 15:04", tc.now)
+			got, ok := days.RunDayStartTime(now)
+			if ok != tc.wantRun {
+				t.Fatalf("RunDayStartTime(%s) ok = %v, want %v", tc.now, ok, tc.wantRun)
+			}
+			if ok && got.Format("2006-01-02 15:04") != tc.want {
+				t.Fatalf("RunDayStartTime(%s) = %s, want %s", tc.now, got.Format("2006-01-02 15:04"), tc.want)
+			}
+		})
+	}
+}
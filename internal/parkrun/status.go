@@ -0,0 +1,95 @@
+package parkrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the lifecycle status of an event; the values are the ones used in the 'status' column of the data sheet.
+type Status string
+
+const (
+	StatusActive            Status = ""
+	StatusPlanned           Status = "geplant"
+	StatusTemporarilyClosed Status = "temporär geschlossen"
+	StatusArchived          Status = "archiviert"
+)
+
+// ParseStatus parses the 'status' column of the data sheet; unknown values are rejected instead of silently treated as archived.
+func ParseStatus(s string) (Status, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return StatusActive, nil
+	case "geplant":
+		return StatusPlanned, nil
+	case "temporär geschlossen":
+		return StatusTemporarilyClosed, nil
+	case "archiviert", "geschlossen":
+		return StatusArchived, nil
+	}
+	return StatusActive, fmt.Errorf("unknown status '%s'", s)
+}
+
+// Label is the German display label of the status.
+func (status Status) Label() string {
+	switch status {
+	case StatusActive:
+		return "aktiv"
+	case StatusPlanned:
+		return "geplant"
+	case StatusTemporarilyClosed:
+		return "temporär geschlossen"
+	}
+	return "archiviert"
+}
+
+// CssClass is the CSS class of the status tag.
+func (status Status) CssClass() string {
+	switch status {
+	case StatusActive, StatusPlanned:
+		return "tag-green"
+	}
+	return "tag-red"
+}
+
+// PlannedStatus is the status of an entry of the list of planned parkruns ('status' column of the planned sheet).
+type PlannedStatus string
+
+const (
+	PlannedStatusRumored   PlannedStatus = ""       // only rumors or first announcements
+	PlannedStatusScheduled PlannedStatus = "termin" // the first run has been scheduled
+	PlannedStatusTest      PlannedStatus = "test"   // test runs are taking place
+)
+
+// ParsePlannedStatus parses the 'status' column of the planned sheet; unknown values are rejected.
+func ParsePlannedStatus(s string) (PlannedStatus, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return PlannedStatusRumored, nil
+	case "termin":
+		return PlannedStatusScheduled, nil
+	case "test":
+		return PlannedStatusTest, nil
+	}
+	return PlannedStatusRumored, fmt.Errorf("unknown planned status '%s'", s)
+}
+
+// Label is the German display label of the planned status.
+func (status PlannedStatus) Label() string {
+	switch status {
+	case PlannedStatusScheduled:
+		return "Termin steht fest"
+	case PlannedStatusTest:
+		return "Testläufe"
+	}
+	return "in Planung"
+}
+
+// CssClass is the CSS class of the planned status tag.
+func (status PlannedStatus) CssClass() string {
+	switch status {
+	case PlannedStatusScheduled, PlannedStatusTest:
+		return "tag-green"
+	}
+	return "tag-orange"
+}
@@ -0,0 +1,67 @@
+package parkrun
+
+import (
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	testCases := []struct {
+		input     string
+		want      Status
+		wantLabel string
+		wantErr   bool
+	}{
+		{input: "", want: StatusActive, wantLabel: "aktiv"},
+		{input: "geplant", want: StatusPlanned, wantLabel: "geplant"},
+		{input: " temporär geschlossen ", want: StatusTemporarilyClosed, wantLabel: "temporär geschlossen"},
+		{input: "archiviert", want: StatusArchived, wantLabel: "archiviert"},
+		{input: "geschlossen", want: StatusArchived, wantLabel: "archiviert"},
+		{input: "aktiv", wantErr: true},
+		{input: "Geplant", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseStatus(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseStatus(%q) = %q, want error", tc.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStatus(%q) error = %v", tc.input, err)
+			}
+			if got != tc.want || got.Label() != tc.wantLabel {
+				t.Fatalf("ParseStatus(%q) = %q (%s), want %q (%s)", tc.input, got, got.Label(), tc.want, tc.wantLabel)
+			}
+		})
+	}
+}
+
+func TestParsePlannedStatus(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    PlannedStatus
+		wantErr bool
+	}{
+		{input: "", want: PlannedStatusRumored},
+		{input: "termin", want: PlannedStatusScheduled},
+		{input: "test", want: PlannedStatusTest},
+		{input: "geplant", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParsePlannedStatus(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePlannedStatus(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Fatalf("ParsePlannedStatus(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
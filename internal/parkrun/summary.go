@@ -0,0 +1,375 @@
+package parkrun
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/flopp/parkrun-map/internal/utils"
+)
+
+// SummaryData is an event's row of the summary wiki page (statistics of all German events).
+type SummaryData struct {
+	Name                 string // event name, e.g. "Allerpark parkrun"
+	Events               int    // number of events held
+	LatestEventId        int
+	LatestDate           time.Time // zero if the table has no date column
+	Runners              int       // runners at the latest event
+	Volunteers           int       // volunteers at the latest event
+	AverageRunners       float64
+	AverageVolunteers    float64
+	TotalRunners         int // runs over all events
+	TotalVolunteers      int // volunteer occasions over all events
+	Participants         int // individual runners
+	IndividualVolunteers int
+	Registrations        int
+	Columns              map[string]string // raw values of all columns by header
+}
+
+// Matches returns true if the summary describes the given run (same run index and number of runners).
+func (summary SummaryData) Matches(run *Run) bool {
+	return run != nil && summary.LatestEventId == run.Index && summary.Runners == run.RunnerCount
+}
+
+// HasLatestRun returns true if the summary contains everything needed to describe the latest run (index, date, runners).
+func (summary SummaryData) HasLatestRun() bool {
+	return summary.LatestEventId > 0 && !summary.LatestDate.IsZero() && summary.Runners > 0
+}
+
+// NewerThan returns true if the summary describes a later run than the given one.
+func (summary SummaryData) NewerThan(run *Run) bool {
+	return summary.HasLatestRun() && (run == nil || summary.LatestEventId > run.Index)
+}
+
+// Covers returns true if the summary describes the given run or a later one, so the event's wiki page doesn't need to be downloaded.
+func (summary SummaryData) Covers(run *Run) bool {
+	if summary.Matches(run) {
+		return true
+	}
+	return summary.HasLatestRun() && (run == nil || summary.LatestEventId >= run.Index)
+}
+
+// applySummaryTotal takes over a total of the summary unless it's implausible: totals never shrink, so a value below the
+// one of the wiki page means that the column has been identified wrongly.
+func (event *Event) applySummaryTotal(name string, total *int, value int) {
+	if value <= 0 {
+		return
+	}
+	if value < *total {
+		log.Printf("parkrun: WARNING: %s of %s in the summary (%d) is lower than on the wiki page (%d); keeping the wiki value", name, event.Id, value, *total)
+		return
+	}
+	*total = value
+}
+
+// ApplySummary stores the summary on the event, takes over its (plausible) totals and uses its latest run if the wiki
+// page of the event is missing or older.
+func (event *Event) ApplySummary(summary *SummaryData) {
+	event.Summary = summary
+	if summary == nil {
+		return
+	}
+	event.applySummaryTotal("total runners", &event.SummaryRunners, summary.TotalRunners)
+	if summary.TotalRunners > 0 && summary.Participants > summary.TotalRunners {
+		log.Printf("parkrun: WARNING: individual runners of %s in the summary (%d) exceed the total runners (%d); ignoring them", event.Id, summary.Participants, summary.TotalRunners)
+	} else {
+		event.applySummaryTotal("individual runners", &event.SummaryIndividualRunners, summary.Participants)
+	}
+	event.applySummaryTotal("total volunteers", &event.SummaryVolunteers, summary.TotalVolunteers)
+	if summary.TotalVolunteers > 0 && summary.IndividualVolunteers > summary.TotalVolunteers {
+		log.Printf("parkrun: WARNING: individual volunteers of %s in the summary (%d) exceed the total volunteers (%d); ignoring them", event.Id, summary.IndividualVolunteers, summary.TotalVolunteers)
+	} else {
+		event.applySummaryTotal("individual volunteers", &event.SummaryIndividualVolunteers, summary.IndividualVolunteers)
+	}
+	event.applySummaryTotal("registrations", &event.SummaryRegistrations, summary.Registrations)
+	if summary.HasLatestRun() && (event.LatestRun == nil || summary.LatestEventId > event.LatestRun.Index || (summary.LatestEventId == event.LatestRun.Index && summary.Runners != event.LatestRun.RunnerCount)) {
+		event.LatestRun = &Run{event, summary.LatestEventId, summary.LatestDate, summary.Runners, nil}
+	}
+}
+
+// summary table columns
+const (
+	summaryName                 = "name"
+	summaryEvents               = "events"
+	summaryLatestEventId        = "latest event"
+	summaryLatestDate           = "latest date"
+	summaryRunners              = "runners"
+	summaryVolunteers           = "volunteers"
+	summaryAverageRunners       = "average runners"
+	summaryAverageVolunteers    = "average volunteers"
+	summaryTotalRunners         = "total runners"
+	summaryTotalVolunteers      = "total volunteers"
+	summaryParticipants         = "participants"
+	summaryIndividualVolunteers = "individual volunteers"
+	summaryRegistrations        = "registrations"
+)
+
+func containsAny(s string, words ...string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryColumn maps a header of the summary table to one of the known columns ("" if unknown).
+func summaryColumn(header string) string {
+	h := strings.ToLower(strings.Join(strings.Fields(header), " "))
+	volunteers := strings.Contains(h, "volunteer")
+	switch {
+	case h == "event" || h == "event name" || h == "parkrun":
+		return summaryName
+	case containsAny(h, "latest", "last", "recent"):
+		switch {
+		case containsAny(h, "date", "when"):
+			return summaryLatestDate
+		case volunteers:
+			return summaryVolunteers
+		case containsAny(h, "runner", "finisher", "participant"):
+			return summaryRunners
+		}
+		return summaryLatestEventId
+	case containsAny(h, "average", "avg", "mean"):
+		if volunteers {
+			return summaryAverageVolunteers
+		}
+		return summaryAverageRunners
+	case containsAny(h, "registration", "registered"):
+		return summaryRegistrations
+	case containsAny(h, "individual", "unique", "different"):
+		if volunteers {
+			return summaryIndividualVolunteers
+		}
+		return summaryParticipants
+	case strings.Contains(h, "events"):
+		// before "total", e.g. "Total Events"
+		return summaryEvents
+	case containsAny(h, "total", "occasions") || h == "runs":
+		if volunteers {
+			return summaryTotalVolunteers
+		}
+		return summaryTotalRunners
+	case h == "participants":
+		return summaryParticipants
+	case containsAny(h, "runner", "finisher"):
+		return summaryRunners
+	case volunteers:
+		return summaryVolunteers
+	case strings.Contains(h, "number"):
+		return summaryEvents
+	}
+	return ""
+}
+
+// summaryFixedColumns is the layout of the summary table that was used before the columns were identified by their
+// headers; it's the fallback if the headers are missing or cannot be identified.
+var summaryFixedColumns = map[string]int{
+	summaryName:          0,
+	summaryRunners:       4,
+	summaryVolunteers:    7,
+	summaryLatestEventId: 10,
+}
+
+// summaryHeaderColumns identifies the known columns by the headers; it returns nil if a required column is missing.
+func summaryHeaderColumns(headers []string) map[string]int {
+	columns := make(map[string]int)
+	for i, header := range headers {
+		if column := summaryColumn(header); column != "" {
+			if _, found := columns[column]; !found {
+				columns[column] = i
+			}
+		}
+	}
+	if _, found := columns[summaryName]; !found {
+		columns[summaryName] = 0
+	}
+	for _, required := range []string{summaryLatestEventId, summaryRunners} {
+		if _, found := columns[required]; !found {
+			return nil
+		}
+	}
+	return columns
+}
+
+// ParseSummaryWiki parses the table of the summary wiki page into a map from event name to summary data.
+// The columns are identified by their headers (falling back to the fixed columns 0, 4, 7 and 10 if that fails); the event
+// name is taken from the title attribute of the link in the name column.
+func ParseSummaryWiki(filePath string) (map[string]*SummaryData, error) {
+	buf, err := utils.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("while reading summary wiki file: %w", err)
+	}
+	doc, err := html.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("while parsing HTML: %w", err)
+	}
+
+	table := findNode(doc, func(n *html.Node) bool { return n.Data == "table" })
+	if table == nil {
+		return nil, fmt.Errorf("cannot find summary table")
+	}
+
+	summaries := make(map[string]*SummaryData)
+	var headers []string
+	var columns map[string]int
+	minCells := 0
+	for _, row := range findNodes(table, func(n *html.Node) bool { return n.Data == "tr" }) {
+		var cells []*html.Node
+		isHeader := false
+		for child := row.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && (child.Data == "td" || child.Data == "th") {
+				cells = append(cells, child)
+				isHeader = isHeader || child.Data == "th"
+			}
+		}
+
+		if columns == nil {
+			if isHeader {
+				for _, cell := range cells {
+					headers = append(headers, nodeText(cell))
+				}
+				columns = summaryHeaderColumns(headers)
+				if columns == nil {
+					log.Printf("parkrun: WARNING: cannot identify the columns of the summary table by its headers (%s); using fixed columns", strings.Join(headers, ", "))
+					columns = summaryFixedColumns
+				}
+			} else {
+				log.Printf("parkrun: WARNING: summary table has no header row; using fixed columns")
+				columns = summaryFixedColumns
+			}
+			minCells = len(headers)
+			for _, i := range columns {
+				if i >= minCells {
+					minCells = i + 1
+				}
+			}
+			if isHeader {
+				continue
+			}
+		}
+
+		if len(cells) < minCells {
+			continue
+		}
+		name := nodeTitle(cells[columns[summaryName]])
+		if name == "" {
+			continue
+		}
+
+		data := &SummaryData{Name: name, Columns: make(map[string]string)}
+		for i, header := range headers {
+			data.Columns[header] = nodeText(cells[i])
+		}
+		value := func(column string) string {
+			if i, found := columns[column]; found {
+				return nodeText(cells[i])
+			}
+			return ""
+		}
+		data.Events = parseSummaryInt(value(summaryEvents))
+		data.LatestEventId = parseSummaryInt(value(summaryLatestEventId))
+		data.Runners = parseSummaryInt(value(summaryRunners))
+		data.Volunteers = parseSummaryInt(value(summaryVolunteers))
+		data.AverageRunners = parseSummaryFloat(value(summaryAverageRunners))
+		data.AverageVolunteers = parseSummaryFloat(value(summaryAverageVolunteers))
+		data.TotalRunners = parseSummaryInt(value(summaryTotalRunners))
+		data.TotalVolunteers = parseSummaryInt(value(summaryTotalVolunteers))
+		data.Participants = parseSummaryInt(value(summaryParticipants))
+		data.IndividualVolunteers = parseSummaryInt(value(summaryIndividualVolunteers))
+		data.Registrations = parseSummaryInt(value(summaryRegistrations))
+		if s := value(summaryLatestDate); s != "" {
+			if date, err := parseDate(s); err == nil {
+				data.LatestDate = date
+			} else if date, err := time.Parse("2006-01-02", s); err == nil {
+				data.LatestDate = date
+			}
+		}
+		summaries[name] = data
+	}
+
+	return summaries, nil
+}
+
+var reSummaryInt = regexp.MustCompile(`^(\d+|\d{1,3}(,\d{3})+)$`)
+
+// parseSummaryInt parses a plain integer with optional thousands separators like "1,234"; it returns 0 for anything else
+// (empty values, dates, "1,234 (5)", ...).
+func parseSummaryInt(s string) int {
+	s = strings.TrimSpace(s)
+	if !reSummaryInt.MatchString(s) {
+		return 0
+	}
+	v, err := strconv.Atoi(strings.ReplaceAll(s, ",", ""))
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func parseSummaryFloat(s string) float64 {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findNode(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findNodes(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var nodes []*html.Node
+	if n.Type == html.ElementNode && match(n) {
+		nodes = append(nodes, n)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, findNodes(child, match)...)
+	}
+	return nodes
+}
+
+// nodeText returns the trimmed text content of the node.
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(sb.String())
+}
+
+// nodeTitle returns the title attribute of the first link within the node.
+func nodeTitle(n *html.Node) string {
+	link := findNode(n, func(node *html.Node) bool { return node.Data == "a" })
+	if link == nil {
+		return ""
+	}
+	for _, attr := range link.Attr {
+		if attr.Key == "title" {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
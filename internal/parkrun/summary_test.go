@@ -0,0 +1,266 @@
+package parkrun
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// summaryWikiHtml is a synthetic table for the header-based parsing; the headers are not taken from the real summary
+// wiki page (the fixed columns of TestParseSummaryWikiFixedColumns are its known layout).
+const summaryWikiHtml = `<html><body>
+<table class="wikitable sortable">
+<tr>
+<th>Event</th>
+<th>Events</th>
+<th>Latest Event</th>
+<th>Latest Date</th>
+<th>Latest Runners</th>
+<th>Average Runners</th>
+<th>Total Runners</th>
+<th>Latest Volunteers</th>
+<th>Average Volunteers</th>
+<th>Total Volunteers</th>
+<th>Unknown Column</th>
+</tr>
+<tr>
+<td><a href="/index.php/Allerpark_parkrun" title="Allerpark parkrun">Allerpark</a>
+</td>
+<td>212
+</td>
+<td>212
+</td>
+<td>06.06.2026
+</td>
+<td>48
+</td>
+<td>41.5
+</td>
+<td>8,798
+</td>
+<td>9
+</td>
+<td>8.2
+</td>
+<td>1,738
+</td>
+<td>foo
+</td>
+</tr>
+<tr>
+<td><a href="/index.php/Neu_parkrun" title="Neu parkrun">Neu</a></td>
+<td>0</td><td></td><td></td><td></td><td></td><td></td><td></td><td></td><td></td><td></td>
+</tr>
+<tr><td>Total</td><td>212</td><td></td><td></td><td></td><td></td><td></td><td></td><td></td><td></td><td></td></tr>
+</table>
+</body></html>`
+
+func TestParseSummaryWiki(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary_wiki")
+	if err := os.WriteFile(path, []byte(summaryWikiHtml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries, err := ParseSummaryWiki(path)
+	if err != nil {
+		t.Fatalf("ParseSummaryWiki() error = %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("ParseSummaryWiki() = %d rows, want 2", len(summaries))
+	}
+
+	got := summaries["Allerpark parkrun"]
+	if got == nil {
+		t.Fatalf("missing row 'Allerpark parkrun'")
+	}
+	want := SummaryData{
+		Name:              "Allerpark parkrun",
+		Events:            212,
+		LatestEventId:     212,
+		LatestDate:        time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC),
+		Runners:           48,
+		Volunteers:        9,
+		AverageRunners:    41.5,
+		AverageVolunteers: 8.2,
+		TotalRunners:      8798,
+		TotalVolunteers:   1738,
+	}
+	if got.Name != want.Name || got.Events != want.Events || got.LatestEventId != want.LatestEventId || !got.LatestDate.Equal(want.LatestDate) ||
+		got.Runners != want.Runners || got.Volunteers != want.Volunteers || got.AverageRunners != want.AverageRunners ||
+		got.AverageVolunteers != want.AverageVolunteers || got.TotalRunners != want.TotalRunners || got.TotalVolunteers != want.TotalVolunteers {
+		t.Fatalf("ParseSummaryWiki() = %+v, want %+v", *got, want)
+	}
+	if got.Columns["Unknown Column"] != "foo" {
+		t.Fatalf("unexpected raw columns: %v", got.Columns)
+	}
+	if !got.HasLatestRun() || summaries["Neu parkrun"].HasLatestRun() {
+		t.Fatalf("unexpected HasLatestRun()")
+	}
+}
+
+func TestSummaryColumn(t *testing.T) {
+	testCases := []struct {
+		header string
+		want   string
+	}{
+		{"Event", summaryName},
+		{"Events", summaryEvents},
+		{"Total Events", summaryEvents},
+		{"Number", summaryEvents},
+		{"Latest Event", summaryLatestEventId},
+		{"Latest  Date", summaryLatestDate},
+		{"Latest Runners", summaryRunners},
+		{"Latest Volunteers", summaryVolunteers},
+		{"Average Runners", summaryAverageRunners},
+		{"Total Runners", summaryTotalRunners},
+		{"Total Volunteers", summaryTotalVolunteers},
+		{"Volunteer occasions", summaryTotalVolunteers},
+		{"Individual Volunteers", summaryIndividualVolunteers},
+		{"Unique Runners", summaryParticipants},
+		{"Registrations", summaryRegistrations},
+		{"Unknown Column", ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.header, func(t *testing.T) {
+			if got := summaryColumn(tc.header); got != tc.want {
+				t.Fatalf("summaryColumn(%q) = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSummaryWikiFixedColumns(t *testing.T) {
+	row := `<tr><td><a href="/index.php/Allerpark_parkrun" title="Allerpark parkrun">Allerpark</a></td>` +
+		`<td>1</td><td>2</td><td>3</td><td>48</td><td>5</td><td>6</td><td>9</td><td>8</td><td>9</td><td>212</td></tr>`
+	testCases := []struct {
+		name   string
+		header string
+	}{
+		{"unknown headers", `<tr><th>Event</th><th>Events</th><th>A</th><th>B</th><th>C</th><th>D</th><th>E</th><th>F</th><th>G</th><th>H</th><th>I</th></tr>`},
+		{"no header", ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "summary_wiki")
+			if err := os.WriteFile(path, []byte("<table>"+tc.header+row+"</table>"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			summaries, err := ParseSummaryWiki(path)
+			if err != nil {
+				t.Fatalf("ParseSummaryWiki() error = %v", err)
+			}
+			got := summaries["Allerpark parkrun"]
+			if got == nil || got.LatestEventId != 212 || got.Runners != 48 || got.Volunteers != 9 {
+				t.Fatalf("ParseSummaryWiki() = %+v", summaries)
+			}
+		})
+	}
+}
+
+func TestApplySummary(t *testing.T) {
+	date := time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC)
+	summary := &SummaryData{LatestEventId: 101, LatestDate: date, Runners: 50, TotalRunners: 5000}
+
+	testCases := []struct {
+		name      string
+		latestRun *Run
+		wantIndex int
+	}{
+		{name: "no wiki data", latestRun: nil, wantIndex: 101},
+		{name: "older wiki data", latestRun: &Run{Index: 100, Date: date.AddDate(0, 0, -7), RunnerCount: 40}, wantIndex: 101},
+		{name: "newer wiki data", latestRun: &Run{Index: 102, Date: date.AddDate(0, 0, 7), RunnerCount: 40}, wantIndex: 102},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{Id: "dietenbach", LatestRun: tc.latestRun}
+			event.ApplySummary(summary)
+			if event.Summary != summary || event.SummaryRunners != 5000 {
+				t.Fatalf("ApplySummary() didn't store the summary")
+			}
+			if event.LatestRun == nil || event.LatestRun.Index != tc.wantIndex {
+				t.Fatalf("ApplySummary() latest run = %v, want index %d", event.LatestRun, tc.wantIndex)
+			}
+		})
+	}
+}
+
+func TestApplySummaryTotals(t *testing.T) {
+	testCases := []struct {
+		name           string
+		summary        SummaryData
+		wantRunners    int
+		wantIndividual int
+	}{
+		{name: "newer totals", summary: SummaryData{TotalRunners: 5100, Participants: 1300}, wantRunners: 5100, wantIndividual: 1300},
+		{name: "no totals", summary: SummaryData{}, wantRunners: 5000, wantIndividual: 1200},
+		{name: "lower total", summary: SummaryData{TotalRunners: 48, Participants: 1300}, wantRunners: 5000, wantIndividual: 1200},
+		{name: "individual above total", summary: SummaryData{TotalRunners: 5100, Participants: 9000}, wantRunners: 5100, wantIndividual: 1200},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{Id: "dietenbach", SummaryRunners: 5000, SummaryIndividualRunners: 1200}
+			summary := tc.summary
+			event.ApplySummary(&summary)
+			if event.SummaryRunners != tc.wantRunners || event.SummaryIndividualRunners != tc.wantIndividual {
+				t.Fatalf("ApplySummary() totals = %d/%d, want %d/%d", event.SummaryRunners, event.SummaryIndividualRunners, tc.wantRunners, tc.wantIndividual)
+			}
+		})
+	}
+}
+
+func TestParseSummaryInt(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want int
+	}{
+		{"48", 48},
+		{" 1,234\n", 1234},
+		{"1,234,567", 1234567},
+		{"", 0},
+		{"06.06.2026", 0},
+		{"2026-06-06", 0},
+		{"1,234 (5)", 0},
+		{"12,34", 0},
+		{"41.5", 0},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.s, func(t *testing.T) {
+			if got := parseSummaryInt(tc.s); got != tc.want {
+				t.Fatalf("parseSummaryInt(%q) = %d, want %d", tc.s, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSummaryNewerThan(t *testing.T) {
+	date := time.Date(2026, 6, 6, 0, 0, 0, 0, time.UTC)
+	summary := SummaryData{LatestEventId: 101, LatestDate: date, Runners: 50}
+
+	testCases := []struct {
+		name string
+		run  *Run
+		want bool
+	}{
+		{"no run", nil, true},
+		{"older run", &Run{Index: 100, RunnerCount: 40}, true},
+		{"same run, other runner count", &Run{Index: 101, RunnerCount: 52}, false},
+		{"newer run", &Run{Index: 102, RunnerCount: 40}, false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := summary.NewerThan(tc.run); got != tc.want {
+				t.Fatalf("NewerThan() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
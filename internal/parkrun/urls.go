@@ -0,0 +1,48 @@
+package parkrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultCountryUrl = "www.parkrun.com.de"
+
+// UrlBuilder builds links to the official parkrun.com pages and the parkrun wiki for a single event.
+type UrlBuilder struct {
+	countryUrl string
+	id         string
+	name       string
+}
+
+// NewUrlBuilder creates a UrlBuilder for the event with the given id and (long) name on the given country domain.
+// An empty countryUrl (e.g. planned events that only exist in the sheet) falls back to the German domain.
+func NewUrlBuilder(countryUrl, id, name string) UrlBuilder {
+	if countryUrl == "" {
+		countryUrl = defaultCountryUrl
+	}
+	return UrlBuilder{countryUrl, id, name}
+}
+
+func (b UrlBuilder) Home() string {
+	return fmt.Sprintf("https://%s/%s", b.countryUrl, b.id)
+}
+
+func (b UrlBuilder) Course() string {
+	return fmt.Sprintf("https://%s/%s/course", b.countryUrl, b.id)
+}
+
+func (b UrlBuilder) Results() string {
+	return fmt.Sprintf("https://%s/%s/results/eventhistory", b.countryUrl, b.id)
+}
+
+func (b UrlBuilder) Run(index int) string {
+	return fmt.Sprintf("https://%s/%s/results/%d/", b.countryUrl, b.id, index)
+}
+
+func (b UrlBuilder) Athlete(athleteId string) string {
+	return fmt.Sprintf("https://%s/%s/parkrunner/%s/", b.countryUrl, b.id, athleteId)
+}
+
+func (b UrlBuilder) Wiki() string {
+	return fmt.Sprintf("https://wiki.parkrun.com/index.php/%s", strings.ReplaceAll(b.name, " ", "_"))
+}
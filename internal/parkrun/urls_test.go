@@ -0,0 +1,92 @@
+package parkrun
+
+import (
+	"testing"
+)
+
+func TestUrlBuilder(t *testing.T) {
+	testCases := []struct {
+		name        string
+		event       Event
+		wantHome    string
+		wantCourse  string
+		wantResults string
+		wantRun     string
+		wantAthlete string
+		wantWiki    string
+	}{
+		{
+			name:        "German event",
+			event:       Event{Id: "dietenbach", Name: "Dietenbach parkrun", CountryUrl: "www.parkrun.com.de"},
+			wantHome:    "https://www.parkrun.com.de/dietenbach",
+			wantCourse:  "https://www.parkrun.com.de/dietenbach/course",
+			wantResults: "https://www.parkrun.com.de/dietenbach/results/eventhistory",
+			wantRun:     "https://www.parkrun.com.de/dietenbach/results/42/",
+			wantAthlete: "https://www.parkrun.com.de/dietenbach/parkrunner/123456/",
+			wantWiki:    "https://wiki.parkrun.com/index.php/Dietenbach_parkrun",
+		},
+		{
+			name:        "non-German event",
+			event:       Event{Id: "bushy", Name: "Bushy parkrun", CountryUrl: "www.parkrun.org.uk"},
+			wantHome:    "https://www.parkrun.org.uk/bushy",
+			wantCourse:  "https://www.parkrun.org.uk/bushy/course",
+			wantResults: "https://www.parkrun.org.uk/bushy/results/eventhistory",
+			wantRun:     "https://www.parkrun.org.uk/bushy/results/42/",
+			wantAthlete: "https://www.parkrun.org.uk/bushy/parkrunner/123456/",
+			wantWiki:    "https://wiki.parkrun.com/index.php/Bushy_parkrun",
+		},
+		{
+			name:        "planned event without country",
+			event:       Event{Id: "stadtparkrotehorn", Name: "Stadtpark Rotehorn parkrun", CountryUrl: ""},
+			wantHome:    "https://www.parkrun.com.de/stadtparkrotehorn",
+			wantCourse:  "https://www.parkrun.com.de/stadtparkrotehorn/course",
+			wantResults: "https://www.parkrun.com.de/stadtparkrotehorn/results/eventhistory",
+			wantRun:     "https://www.parkrun.com.de/stadtparkrotehorn/results/42/",
+			wantAthlete: "https://www.parkrun.com.de/stadtparkrotehorn/parkrunner/123456/",
+			wantWiki:    "https://wiki.parkrun.com/index.php/Stadtpark_Rotehorn_parkrun",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			urls := tc.event.Urls()
+			if got := urls.Home(); got != tc.wantHome {
+				t.Fatalf("Home() = %q, want %q", got, tc.wantHome)
+			}
+			if got := urls.Course(); got != tc.wantCourse {
+				t.Fatalf("Course() = %q, want %q", got, tc.wantCourse)
+			}
+			if got := urls.Results(); got != tc.wantResults {
+				t.Fatalf("Results() = %q, want %q", got, tc.wantResults)
+			}
+			if got := urls.Run(42); got != tc.wantRun {
+				t.Fatalf("Run() = %q, want %q", got, tc.wantRun)
+			}
+			if got := urls.Athlete("123456"); got != tc.wantAthlete {
+				t.Fatalf("Athlete() = %q, want %q", got, tc.wantAthlete)
+			}
+			if got := urls.Wiki(); got != tc.wantWiki {
+				t.Fatalf("Wiki() = %q, want %q", got, tc.wantWiki)
+			}
+
+			// the Event/Run helpers used by the templates must agree with the builder
+			if got := tc.event.Url(); got != tc.wantHome {
+				t.Fatalf("Event.Url() = %q, want %q", got, tc.wantHome)
+			}
+			if got := tc.event.CoursePageUrl(); got != tc.wantCourse {
+				t.Fatalf("Event.CoursePageUrl() = %q, want %q", got, tc.wantCourse)
+			}
+			if got := tc.event.ResultsUrl(); got != tc.wantResults {
+				t.Fatalf("Event.ResultsUrl() = %q, want %q", got, tc.wantResults)
+			}
+			if got := tc.event.WikiUrl(); got != tc.wantWiki {
+				t.Fatalf("Event.WikiUrl() = %q, want %q", got, tc.wantWiki)
+			}
+			run := Run{Event: &tc.event, Index: 42}
+			if got := run.Url(); got != tc.wantRun {
+				t.Fatalf("Run.Url() = %q, want %q", got, tc.wantRun)
+			}
+		})
+	}
+}
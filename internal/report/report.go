@@ -0,0 +1,231 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a single result of a check.
+type Finding struct {
+	Event    string `json:"event,omitempty"` // the event ID (empty if the finding concerns the whole site)
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Evidence string `json:"evidence,omitempty"` // e.g. the checked URL or the compared values
+}
+
+func (f Finding) String() string {
+	location := f.Check
+	if f.Event != "" {
+		location = fmt.Sprintf("%s (%s)", f.Check, f.Event)
+	}
+	s := fmt.Sprintf("%s: %s: %s", location, f.Severity, f.Message)
+	if f.Evidence != "" {
+		s += fmt.Sprintf(" [%s]", f.Evidence)
+	}
+	return s
+}
+
+// Report collects the findings of all checks; the checked subjects are kept for the JUnit output (one test case per subject).
+type Report struct {
+	Findings []Finding `json:"findings"`
+
+	checked map[string][]string
+}
+
+func New() *Report {
+	return &Report{Findings: make([]Finding, 0), checked: make(map[string][]string)}
+}
+
+// Checked records that the check ran for the event (or the whole site for an empty event).
+func (r *Report) Checked(check string, event string) {
+	r.checked[check] = append(r.checked[check], event)
+}
+
+func (r *Report) Add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+func (r *Report) Error(check, event, evidence string, format string, args ...any) {
+	r.Add(Finding{Event: event, Check: check, Severity: SeverityError, Message: fmt.Sprintf(format, args...), Evidence: evidence})
+}
+
+func (r *Report) Warning(check, event, evidence string, format string, args ...any) {
+	r.Add(Finding{Event: event, Check: check, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...), Evidence: evidence})
+}
+
+func (r *Report) Info(check, event, evidence string, format string, args ...any) {
+	r.Add(Finding{Event: event, Check: check, Severity: SeverityInfo, Message: fmt.Sprintf(format, args...), Evidence: evidence})
+}
+
+// Count returns the number of findings with the severity.
+func (r *Report) Count(severity string) int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			count += 1
+		}
+	}
+	return count
+}
+
+// HasErrors returns true if at least one of the findings is an error.
+func (r *Report) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// sorted returns the findings ordered by check, event and severity.
+func (r *Report) sorted() []Finding {
+	severityOrder := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+	findings := append([]Finding(nil), r.Findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Check != b.Check {
+			return a.Check < b.Check
+		}
+		if a.Event != b.Event {
+			return a.Event < b.Event
+		}
+		return severityOrder[a.Severity] < severityOrder[b.Severity]
+	})
+	return findings
+}
+
+// WriteText writes one line per finding and a summary.
+func (r *Report) WriteText(w io.Writer) error {
+	for _, f := range r.sorted() {
+		if _, err := fmt.Fprintln(w, f); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings, %d infos\n", r.Count(SeverityError), r.Count(SeverityWarning), r.Count(SeverityInfo))
+	return err
+}
+
+// WriteJSON writes the findings as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Errors   int       `json:"errors"`
+		Warnings int       `json:"warnings"`
+		Findings []Finding `json:"findings"`
+	}{r.Count(SeverityError), r.Count(SeverityWarning), r.sorted()})
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// WriteJUnit writes the report as JUnit XML for CI systems: one test suite per check, one test case per checked event;
+// errors are failures, warnings and infos are added to the output of the test case.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	// subjects by check, including the subjects that only appear in findings
+	subjects := make(map[string][]string)
+	seen := make(map[string]bool)
+	addSubject := func(check, event string) {
+		if key := check + "\x00" + event; !seen[key] {
+			seen[key] = true
+			subjects[check] = append(subjects[check], event)
+		}
+	}
+	for check, events := range r.checked {
+		for _, event := range events {
+			addSubject(check, event)
+		}
+	}
+	findings := make(map[string][]Finding)
+	for _, f := range r.sorted() {
+		addSubject(f.Check, f.Event)
+		key := f.Check + "\x00" + f.Event
+		findings[key] = append(findings[key], f)
+	}
+
+	checks := make([]string, 0, len(subjects))
+	for check := range subjects {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	suites := junitTestSuites{TestSuites: make([]junitTestSuite, 0, len(checks))}
+	for _, check := range checks {
+		suite := junitTestSuite{Name: check}
+		events := subjects[check]
+		sort.Strings(events)
+		for _, event := range events {
+			name := event
+			if name == "" {
+				name = "site"
+			}
+			testCase := junitTestCase{Name: name, ClassName: check}
+			for _, f := range findings[check+"\x00"+event] {
+				line := f.Severity + ": " + f.Message
+				if f.Evidence != "" {
+					line += " [" + f.Evidence + "]"
+				}
+				if f.Severity == SeverityError {
+					if testCase.Failure == nil {
+						testCase.Failure = &junitFailure{Type: check, Message: f.Message}
+					}
+					testCase.Failure.Text += line + "\n"
+				} else {
+					testCase.SystemOut += line + "\n"
+				}
+			}
+			if testCase.Failure != nil {
+				suite.Failures += 1
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
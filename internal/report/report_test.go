@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testReport() *Report {
+	r := New()
+	r.Checked("course", "dietenbach")
+	r.Checked("course", "hasenheide")
+	r.Checked("links", "dietenbach")
+	r.Error("course", "hasenheide", "course page: a, data source: b", "route ID mismatch")
+	r.Warning("links", "dietenbach", "https://example.com", "instagram link: 404")
+	r.Info("links", "dietenbach", "", "redirected")
+	r.Error("cancellations", "", "Foo parkrun", "unknown event")
+	return r
+}
+
+func TestReportText(t *testing.T) {
+	var buf bytes.Buffer
+	r := testReport()
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `cancellations: error: unknown event [Foo parkrun]
+course (hasenheide): error: route ID mismatch [course page: a, data source: b]
+links (dietenbach): warning: instagram link: 404 [https://example.com]
+links (dietenbach): info: redirected
+2 errors, 1 warnings, 1 infos
+`
+	if buf.String() != want {
+		t.Fatalf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if !r.HasErrors() {
+		t.Fatalf("HasErrors() = false")
+	}
+	if New().HasErrors() {
+		t.Fatalf("HasErrors() of empty report = true")
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded struct {
+		Errors   int       `json:"errors"`
+		Warnings int       `json:"warnings"`
+		Findings []Finding `json:"findings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v\n%s", err, buf.String())
+	}
+	if decoded.Errors != 2 || decoded.Warnings != 1 || len(decoded.Findings) != 4 {
+		t.Fatalf("unexpected JSON report:\n%s", buf.String())
+	}
+	if f := decoded.Findings[1]; f.Event != "hasenheide" || f.Check != "course" || f.Severity != SeverityError || f.Evidence != "course page: a, data source: b" {
+		t.Fatalf("unexpected finding %+v", f)
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("Unmarshal() error = %v\n%s", err, buf.String())
+	}
+	if suites.Tests != 4 || suites.Failures != 2 || len(suites.TestSuites) != 3 {
+		t.Fatalf("unexpected JUnit report:\n%s", buf.String())
+	}
+	course := suites.TestSuites[1]
+	if course.Name != "course" || course.Tests != 2 || course.Failures != 1 || course.TestCases[0].Failure != nil || course.TestCases[1].Failure == nil {
+		t.Fatalf("unexpected course suite %+v", course)
+	}
+	links := suites.TestSuites[2]
+	if links.TestCases[0].Failure != nil || !strings.Contains(links.TestCases[0].SystemOut, "warning: instagram link: 404") {
+		t.Fatalf("unexpected links suite %+v", links)
+	}
+	if suites.TestSuites[0].TestCases[0].Name != "site" {
+		t.Fatalf("unexpected site test case %+v", suites.TestSuites[0])
+	}
+}
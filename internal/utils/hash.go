@@ -46,6 +46,10 @@ func CopyHash(src, dst, dstDir string) (string, error) {
 
 	dstHash := strings.Replace(dst, "HASH", hash, -1)
 	dstHash2 := filepath.Join(dstDir, dstHash)
+	// keep an identical destination file (and its modification time)
+	if existingHash, err := ComputeHash(dstHash2); err == nil && existingHash == hash {
+		return dstHash, nil
+	}
 	destination, err := os.Create(dstHash2)
 	if err != nil {
 		return "", err
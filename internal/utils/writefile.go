@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"compress/gzip"
 	"os"
 	"path/filepath"
@@ -32,3 +33,12 @@ func WriteFile(filePath string, data []byte) error {
 		return err
 	}
 }
+
+// WriteFileIfChanged writes the file only if its content differs from data (keeping the modification time of unchanged files);
+// it returns whether the file has been written.
+func WriteFileIfChanged(filePath string, data []byte) (bool, error) {
+	if existing, err := os.ReadFile(filePath); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	return true, WriteFile(filePath, data)
+}